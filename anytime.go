@@ -0,0 +1,116 @@
+package bombahead
+
+import (
+	"context"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// ActionSink collects the actions an AnytimeBot publishes during a tick
+type ActionSink interface {
+	// Publish replaces the current best action
+	// Calls after the deadline are ignored
+	Publish(action Action)
+}
+
+// AnytimeBot can be implemented by a Bot that keeps refining its answer until time runs out
+// SearchNextMove is called instead of GetNextMove. It publishes progressively better actions
+// to sink and should return once ctx is done; the client sends the last published action when
+// the move deadline hits or SearchNextMove returns, whichever happens first.
+// Without WithMoveDeadline the client waits for SearchNextMove to return
+type AnytimeBot interface {
+	Bot
+	SearchNextMove(ctx context.Context, state *GameState, helpers *GameHelpers, sink ActionSink)
+}
+
+// actionSink is the ActionSink handed to an AnytimeBot for one tick
+type actionSink struct {
+	// ctx is the move context; actions published after it is done are ignored
+	ctx       context.Context
+	mu        sync.Mutex
+	action    Action
+	published bool
+	sealed    bool
+}
+
+func (s *actionSink) Publish(action Action) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sealed || (s.ctx != nil && s.ctx.Err() != nil) {
+		return
+	}
+	s.action = action
+	s.published = true
+}
+
+// seal stops accepting actions and returns the last published one
+func (s *actionSink) seal() (Action, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sealed = true
+	return s.action, s.published
+}
+
+// decideAnytime runs an AnytimeBot until the deadline and returns its best published action
+func (r *runner) decideAnytime(ctx context.Context, bot AnytimeBot, t turn) Action {
+	if r.stillBusy() {
+		r.stats.Busy++
+		log.Printf("Bot still busy with an earlier tick, sending fallback")
+		return r.cfg.fallback(t.state, t.helpers)
+	}
+
+	moveCtx, cancel := ctx, context.CancelFunc(func() {})
+	if r.cfg.moveDeadline > 0 {
+		moveCtx, cancel = context.WithTimeout(ctx, r.cfg.moveDeadline)
+	}
+	defer cancel()
+
+	sink := &actionSink{ctx: moveCtx}
+	start := time.Now()
+	done := make(chan botResult, 1)
+	go func() {
+		done <- searchWithRecover(moveCtx, bot, t, sink, r.cfg.panicDumpDir)
+	}()
+
+	late := false
+	select {
+	case res := <-done:
+		if res.panicked {
+			sink.seal()
+			return r.panicFallback(t)
+		}
+	case <-moveCtx.Done():
+		r.pending = done
+		late = true
+	}
+
+	action, ok := sink.seal()
+	if !ok {
+		if late {
+			r.stats.Late++
+			log.Printf("Bot published no action before the deadline, sending fallback")
+		} else {
+			r.stats.Unpublished++
+			log.Printf("Bot returned without publishing an action, sending fallback")
+		}
+		return r.cfg.fallback(t.state, NewGameHelpers(t.state))
+	}
+
+	r.recordLatency(time.Since(start))
+	return action
+}
+
+// searchWithRecover runs SearchNextMove and recovers a panic inside it
+func searchWithRecover(ctx context.Context, bot AnytimeBot, t turn, sink ActionSink, dumpDir string) (res botResult) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			reportPanic(dumpDir, t, rec, debug.Stack())
+			res = botResult{panicked: true}
+		}
+	}()
+
+	bot.SearchNextMove(ctx, t.state, t.helpers, sink)
+	return botResult{}
+}
@@ -0,0 +1,104 @@
+package bombahead
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type iterativeBot struct {
+	steps []Action
+	wait  bool
+}
+
+func (b *iterativeBot) GetNextMove(*GameState, *GameHelpers) Action {
+	return DoNothing
+}
+
+func (b *iterativeBot) SearchNextMove(ctx context.Context, _ *GameState, _ *GameHelpers, sink ActionSink) {
+	for _, step := range b.steps {
+		sink.Publish(step)
+	}
+	if b.wait {
+		<-ctx.Done()
+		sink.Publish(PlaceBomb)
+	}
+}
+
+func TestDecideAnytime_SendsLastPublishedAtDeadline(t *testing.T) {
+	t.Parallel()
+
+	bot := &iterativeBot{steps: []Action{MoveLeft, MoveRight}, wait: true}
+	r := &runner{bot: bot, cfg: newConfig([]Option{WithMoveDeadline(10 * time.Millisecond)})}
+	state := openFieldState()
+
+	if got := r.decide(context.Background(), turn{state: state, helpers: NewGameHelpers(state)}); got != MoveRight {
+		t.Fatalf("decide() = %q, want %q", got, MoveRight)
+	}
+	if r.stats.Late != 0 {
+		t.Fatalf("stats.Late = %d, want 0", r.stats.Late)
+	}
+}
+
+func TestDecideAnytime_ReturnsEarlyWithoutDeadline(t *testing.T) {
+	t.Parallel()
+
+	bot := &iterativeBot{steps: []Action{MoveUp, MoveDown}}
+	r := &runner{bot: bot, cfg: newConfig(nil)}
+	state := openFieldState()
+
+	if got := r.decide(context.Background(), turn{state: state, helpers: NewGameHelpers(state)}); got != MoveDown {
+		t.Fatalf("decide() = %q, want %q", got, MoveDown)
+	}
+}
+
+func TestDecideAnytime_FallbackWhenNothingPublished(t *testing.T) {
+	t.Parallel()
+
+	bot := &iterativeBot{wait: true}
+	r := &runner{bot: bot, cfg: newConfig([]Option{
+		WithMoveDeadline(5 * time.Millisecond),
+		WithFallback(func(*GameState, *GameHelpers) Action { return MoveLeft }),
+	})}
+	state := openFieldState()
+
+	if got := r.decide(context.Background(), turn{state: state, helpers: NewGameHelpers(state)}); got != MoveLeft {
+		t.Fatalf("decide() = %q, want fallback %q", got, MoveLeft)
+	}
+	if r.stats.Late != 1 {
+		t.Fatalf("stats.Late = %d, want 1", r.stats.Late)
+	}
+}
+
+func TestDecideAnytime_FallbackWhenReturnedWithoutPublishing(t *testing.T) {
+	t.Parallel()
+
+	for _, opts := range [][]Option{nil, {WithMoveDeadline(time.Minute)}} {
+		r := &runner{bot: &iterativeBot{}, cfg: newConfig(append(opts, WithFallback(func(*GameState, *GameHelpers) Action { return MoveLeft })))}
+		state := openFieldState()
+
+		if got := r.decide(context.Background(), turn{state: state, helpers: NewGameHelpers(state)}); got != MoveLeft {
+			t.Fatalf("decide() = %q, want fallback %q", got, MoveLeft)
+		}
+		if r.stats.Late != 0 || r.stats.Unpublished != 1 || r.stats.Fallbacks() != 1 {
+			t.Fatalf("stats = %+v, want one unpublished tick and no late one", r.stats)
+		}
+	}
+}
+
+func TestActionSink_IgnoresPublishAfterSeal(t *testing.T) {
+	t.Parallel()
+
+	sink := &actionSink{}
+	if _, ok := sink.seal(); ok {
+		t.Fatal("seal() on empty sink reported a published action")
+	}
+
+	sink = &actionSink{}
+	sink.Publish(MoveUp)
+	sink.seal()
+	sink.Publish(MoveDown)
+	if got, ok := sink.seal(); !ok || got != MoveUp {
+		t.Fatalf("seal() = (%q, %v), want (%q, true)", got, ok, MoveUp)
+	}
+}
@@ -0,0 +1,543 @@
+package bombahead
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ASCII grid symbols used by Render and ParseASCII
+// Bombs are shown as their fuse digit and players as 'A' + their index in Players,
+// in lower case when they stand on a bomb
+const (
+	asciiWall      = '#'
+	asciiBox       = '+'
+	asciiAir       = '.'
+	asciiExplosion = '*'
+)
+
+// asciiDefaultHealth and asciiDefaultFuse fill in values a hand-written grid leaves out
+const (
+	asciiDefaultHealth = 3
+	asciiDefaultFuse   = 3
+)
+
+// String returns Render(), so states print as a grid with fmt and log
+func (s *GameState) String() string {
+	if s == nil {
+		return "<nil>"
+	}
+	return s.Render()
+}
+
+// Render draws the state as an ASCII grid followed by a legend that ParseASCII reads back:
+//
+//	tick 12
+//	#######
+//	#A..+.#
+//	#.#2#.#
+//	#..*b.#
+//	#######
+//	A p1 hp=3 score=0 me
+//	B p2 hp=2 score=5
+//	bomb 4,3 fuse=1
+//	item 5,1 BOMB_RANGE
+//
+// '#' is a wall, '+' a box, '.' air and '*' an explosion
+// Bombs, players and explosions the grid cannot show are listed in the legend, items always are.
+// Bomb ranges and the range=, bombs= and speed= player attributes are written when set.
+// IDs that are empty or contain spaces or quotes are written as Go string literals.
+// Only the first 26 players fit on the grid; later ones are labelled AA, AB and so on and always get at=x,y
+func (s *GameState) Render() string {
+	w, h := s.Field.Width, s.Field.Height
+	grid := make([][]byte, h)
+	for y := range grid {
+		grid[y] = make([]byte, w)
+		for x := range grid[y] {
+			switch s.Field.CellAt(Position{X: x, Y: y}) {
+			case Wall:
+				grid[y][x] = asciiWall
+			case Box:
+				grid[y][x] = asciiBox
+			default:
+				grid[y][x] = asciiAir
+			}
+		}
+	}
+	inBounds := func(p Position) bool { return p.X >= 0 && p.X < w && p.Y >= 0 && p.Y < h }
+
+	var hiddenExplosions []Position
+	for _, e := range s.Explosions {
+		if inBounds(e) && grid[e.Y][e.X] == asciiAir {
+			grid[e.Y][e.X] = asciiExplosion
+			continue
+		}
+		hiddenExplosions = append(hiddenExplosions, e)
+	}
+	// Explosions drawn on cells that bombs or players cover are listed in the legend too
+	shownExplosion := func(p Position) bool { return inBounds(p) && grid[p.Y][p.X] == asciiExplosion }
+
+	bombShown := make([]bool, len(s.Bombs))
+	for i, b := range s.Bombs {
+		if !inBounds(b.Pos) || (grid[b.Pos.Y][b.Pos.X] != asciiAir && grid[b.Pos.Y][b.Pos.X] != asciiExplosion) {
+			continue
+		}
+		if shownExplosion(b.Pos) {
+			hiddenExplosions = append(hiddenExplosions, b.Pos)
+		}
+		if b.Fuse >= 0 && b.Fuse <= 9 {
+			grid[b.Pos.Y][b.Pos.X] = byte('0' + b.Fuse)
+			bombShown[i] = true
+		} else {
+			grid[b.Pos.Y][b.Pos.X] = '9'
+		}
+	}
+
+	players := s.renderedPlayers()
+	playerShown := make([]bool, len(players))
+	for i, p := range players {
+		if i >= 26 || !inBounds(p.Pos) {
+			continue
+		}
+		c := grid[p.Pos.Y][p.Pos.X]
+		switch {
+		case c == asciiAir:
+			grid[p.Pos.Y][p.Pos.X] = byte('A' + i)
+		case c == asciiExplosion:
+			hiddenExplosions = append(hiddenExplosions, p.Pos)
+			grid[p.Pos.Y][p.Pos.X] = byte('A' + i)
+		case c >= '0' && c <= '9':
+			grid[p.Pos.Y][p.Pos.X] = byte('a' + i)
+			for j, b := range s.Bombs {
+				if b.Pos == p.Pos {
+					bombShown[j] = false
+				}
+			}
+		default:
+			continue
+		}
+		playerShown[i] = true
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "tick %d", s.CurrentTick)
+	if s.Round != 0 {
+		fmt.Fprintf(&sb, " round %d", s.Round)
+	}
+	if s.MaxTicks != 0 {
+		fmt.Fprintf(&sb, " maxTicks %d", s.MaxTicks)
+	}
+	sb.WriteByte('\n')
+	for _, row := range grid {
+		sb.Write(row)
+		sb.WriteByte('\n')
+	}
+	for i, p := range players {
+		fmt.Fprintf(&sb, "%s %s hp=%d score=%d", asciiPlayerLabel(i), asciiQuoteID(p.ID), p.Health, p.Score)
+		if p.BombRange != 0 {
+			fmt.Fprintf(&sb, " range=%d", p.BombRange)
+		}
+		if p.MaxBombs != 0 {
+			fmt.Fprintf(&sb, " bombs=%d", p.MaxBombs)
+		}
+		if p.Speed != 0 {
+			fmt.Fprintf(&sb, " speed=%d", p.Speed)
+		}
+		if !playerShown[i] {
+			fmt.Fprintf(&sb, " at=%d,%d", p.Pos.X, p.Pos.Y)
+		}
+		if s.Me != nil && s.Me.ID == p.ID {
+			sb.WriteString(" me")
+		}
+		sb.WriteByte('\n')
+	}
+	for i, b := range s.Bombs {
+		if bombShown[i] && b.Owner == "" && b.Range == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "bomb %d,%d fuse=%d", b.Pos.X, b.Pos.Y, b.Fuse)
+		if b.Owner != "" {
+			fmt.Fprintf(&sb, " owner=%s", asciiQuoteID(b.Owner))
+		}
+		if b.Range != 0 {
+			fmt.Fprintf(&sb, " range=%d", b.Range)
+		}
+		sb.WriteByte('\n')
+	}
+	for _, it := range s.Items {
+		fmt.Fprintf(&sb, "item %d,%d %s\n", it.Pos.X, it.Pos.Y, it.Kind)
+	}
+	for _, e := range hiddenExplosions {
+		fmt.Fprintf(&sb, "explosion %d,%d\n", e.X, e.Y)
+	}
+	return sb.String()
+}
+
+// renderedPlayers returns Players, or Me and Opponents for states built without Players
+func (s *GameState) renderedPlayers() []Player {
+	if len(s.Players) > 0 {
+		return s.Players
+	}
+	var players []Player
+	if s.Me != nil {
+		players = append(players, *s.Me)
+	}
+	return append(players, s.Opponents...)
+}
+
+// ParseASCII builds a GameState from the format written by Render
+// Leading indentation and blank lines are ignored, so fixtures can be raw string literals
+// The legend is optional: a player without a legend line gets the ID "p1" for 'A', "p2" for 'B' and so on,
+// 3 health and a score of 0, and a bomb under a lower case player gets a fuse of 3
+// Me is the player marked "me", or the first player
+func ParseASCII(s string) (*GameState, error) {
+	state := &GameState{}
+	var rows []string
+	gridDone := false
+
+	type playerLine struct {
+		player Player
+		hasPos bool
+		me     bool
+	}
+	legend := make(map[int]*playerLine)
+	var bombLines []Bomb
+	var explosionLines []Position
+
+	sc := bufio.NewScanner(strings.NewReader(s))
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		fields, err := splitASCIIFields(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if len(fields) == 1 {
+			if gridDone {
+				return nil, fmt.Errorf("line %d: grid row after the legend", lineNo)
+			}
+			rows = append(rows, line)
+			continue
+		}
+		if len(rows) > 0 {
+			gridDone = true
+		}
+
+		switch key := fields[0]; {
+		case key == "tick":
+			err = parseASCIIHeader(fields, state)
+		case key == "bomb":
+			var b Bomb
+			b, err = parseASCIIBomb(fields)
+			bombLines = append(bombLines, b)
+		case key == "explosion":
+			var p Position
+			p, err = parseASCIIPosition(fields[1])
+			explosionLines = append(explosionLines, p)
+		case key == "item":
+			var it Item
+			it, err = parseASCIIItem(fields)
+			state.Items = append(state.Items, it)
+		case isASCIIPlayerLabel(key):
+			pl := &playerLine{player: Player{ID: fields[1], Health: asciiDefaultHealth}}
+			for _, f := range fields[2:] {
+				k, v, _ := strings.Cut(f, "=")
+				switch k {
+				case "hp":
+					pl.player.Health, err = strconv.Atoi(v)
+				case "score":
+					pl.player.Score, err = strconv.Atoi(v)
+				case "range":
+					pl.player.BombRange, err = strconv.Atoi(v)
+				case "bombs":
+					pl.player.MaxBombs, err = strconv.Atoi(v)
+				case "speed":
+					pl.player.Speed, err = strconv.Atoi(v)
+				case "at":
+					pl.player.Pos, err = parseASCIIPosition(v)
+					pl.hasPos = true
+				case "me":
+					pl.me = true
+				default:
+					err = fmt.Errorf("unknown player attribute %q", f)
+				}
+				if err != nil {
+					break
+				}
+			}
+			legend[asciiPlayerIndex(key)] = pl
+		default:
+			err = fmt.Errorf("unknown legend line %q", line)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+	}
+	if len(rows) == 0 {
+		return nil, errors.New("no grid rows")
+	}
+
+	w, h := len(rows[0]), len(rows)
+	state.Field = Field{Width: w, Height: h, Cells: make([]CellType, w*h)}
+	found := make(map[int]Position)
+	for y, row := range rows {
+		if len(row) != w {
+			return nil, fmt.Errorf("row %d has %d cells, want %d", y, len(row), w)
+		}
+		for x := 0; x < w; x++ {
+			pos := Position{X: x, Y: y}
+			cell := Air
+			switch c := row[x]; {
+			case c == asciiWall:
+				cell = Wall
+			case c == asciiBox:
+				cell = Box
+			case c == asciiAir:
+			case c == asciiExplosion:
+				state.Explosions = append(state.Explosions, pos)
+			case c >= '0' && c <= '9':
+				state.Bombs = append(state.Bombs, Bomb{Pos: pos, Fuse: int(c - '0')})
+			case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z':
+				i := int(c|0x20) - 'a'
+				if _, dup := found[i]; dup {
+					return nil, fmt.Errorf("player %c appears twice", 'A'+i)
+				}
+				found[i] = pos
+				if c >= 'a' {
+					state.Bombs = append(state.Bombs, Bomb{Pos: pos, Fuse: asciiDefaultFuse})
+				}
+			default:
+				return nil, fmt.Errorf("row %d: unknown cell %q", y, c)
+			}
+			state.Field.Cells[y*w+x] = cell
+		}
+	}
+
+	for _, b := range bombLines {
+		merged := false
+		for i := range state.Bombs {
+			if state.Bombs[i].Pos == b.Pos {
+				state.Bombs[i] = b
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			state.Bombs = append(state.Bombs, b)
+		}
+	}
+	state.Explosions = append(state.Explosions, explosionLines...)
+
+	maxIndex := -1
+	for i := range found {
+		maxIndex = max(maxIndex, i)
+	}
+	for i := range legend {
+		maxIndex = max(maxIndex, i)
+	}
+	meIndex := -1
+	for i := 0; i <= maxIndex; i++ {
+		pos, onGrid := found[i]
+		pl, inLegend := legend[i]
+		label := asciiPlayerLabel(i)
+		switch {
+		case !onGrid && !inLegend:
+			return nil, fmt.Errorf("player %s is missing, players must be lettered without gaps", label)
+		case !inLegend:
+			pl = &playerLine{player: Player{ID: "p" + strconv.Itoa(i+1), Health: asciiDefaultHealth}}
+		case !onGrid && !pl.hasPos:
+			return nil, fmt.Errorf("player %s is neither on the grid nor has at=x,y", label)
+		}
+		if onGrid && !pl.hasPos {
+			pl.player.Pos = pos
+		}
+		if pl.me {
+			meIndex = i
+		}
+		state.Players = append(state.Players, pl.player)
+	}
+
+	if len(state.Players) > 0 {
+		if meIndex < 0 {
+			meIndex = 0
+		}
+		me := state.Players[meIndex]
+		state.Me = &me
+		for i, p := range state.Players {
+			if i != meIndex {
+				state.Opponents = append(state.Opponents, p)
+			}
+		}
+	}
+	return state, nil
+}
+
+// MustParseASCII is like ParseASCII but panics on error, for test fixtures
+func MustParseASCII(s string) *GameState {
+	state, err := ParseASCII(s)
+	if err != nil {
+		panic(fmt.Sprintf("bombahead: ParseASCII: %v", err))
+	}
+	return state
+}
+
+func parseASCIIHeader(fields []string, state *GameState) error {
+	if len(fields)%2 != 0 {
+		return fmt.Errorf("header %q is not key value pairs", strings.Join(fields, " "))
+	}
+	for i := 0; i < len(fields); i += 2 {
+		n, err := strconv.Atoi(fields[i+1])
+		if err != nil {
+			return fmt.Errorf("header %s: %w", fields[i], err)
+		}
+		switch fields[i] {
+		case "tick":
+			state.CurrentTick = n
+		case "round":
+			state.Round = n
+		case "maxTicks":
+			state.MaxTicks = n
+		default:
+			return fmt.Errorf("unknown header %q", fields[i])
+		}
+	}
+	return nil
+}
+
+func parseASCIIBomb(fields []string) (Bomb, error) {
+	pos, err := parseASCIIPosition(fields[1])
+	if err != nil {
+		return Bomb{}, err
+	}
+	b := Bomb{Pos: pos, Fuse: asciiDefaultFuse}
+	for _, f := range fields[2:] {
+		k, v, _ := strings.Cut(f, "=")
+		switch k {
+		case "fuse":
+			if b.Fuse, err = strconv.Atoi(v); err != nil {
+				return Bomb{}, fmt.Errorf("bomb fuse: %w", err)
+			}
+		case "owner":
+			b.Owner = v
+		case "range":
+			if b.Range, err = strconv.Atoi(v); err != nil {
+				return Bomb{}, fmt.Errorf("bomb range: %w", err)
+			}
+		default:
+			return Bomb{}, fmt.Errorf("unknown bomb attribute %q", f)
+		}
+	}
+	return b, nil
+}
+
+func parseASCIIItem(fields []string) (Item, error) {
+	if len(fields) != 3 {
+		return Item{}, fmt.Errorf("item %q, want item x,y KIND", strings.Join(fields, " "))
+	}
+	pos, err := parseASCIIPosition(fields[1])
+	if err != nil {
+		return Item{}, err
+	}
+	kind := PowerUpKind(fields[2])
+	switch kind {
+	case PowerUpBombRange, PowerUpExtraBomb, PowerUpSpeed:
+	default:
+		return Item{}, fmt.Errorf("unknown item kind %q", fields[2])
+	}
+	return Item{Pos: pos, Kind: kind}, nil
+}
+
+func parseASCIIPosition(s string) (Position, error) {
+	xs, ys, ok := strings.Cut(s, ",")
+	x, errX := strconv.Atoi(xs)
+	y, errY := strconv.Atoi(ys)
+	if !ok || errX != nil || errY != nil {
+		return Position{}, fmt.Errorf("invalid position %q, want x,y", s)
+	}
+	return Position{X: x, Y: y}, nil
+}
+
+// maxASCIILabelLen bounds legend labels, so their index cannot overflow
+const maxASCIILabelLen = 4
+
+// asciiPlayerLabel returns the legend label of player i: A to Z, then AA, AB and so on
+func asciiPlayerLabel(i int) string {
+	var label []byte
+	for i++; i > 0; i = (i - 1) / 26 {
+		label = append([]byte{byte('A' + (i-1)%26)}, label...)
+	}
+	return string(label)
+}
+
+// isASCIIPlayerLabel reports whether s is a label written by asciiPlayerLabel
+func isASCIIPlayerLabel(s string) bool {
+	if s == "" || len(s) > maxASCIILabelLen {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < 'A' || s[i] > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// asciiPlayerIndex is the inverse of asciiPlayerLabel for labels accepted by isASCIIPlayerLabel
+func asciiPlayerIndex(label string) int {
+	n := 0
+	for i := 0; i < len(label); i++ {
+		n = n*26 + int(label[i]-'A') + 1
+	}
+	return n - 1
+}
+
+// asciiQuoteID quotes id if splitASCIIFields would not read it back as a single field
+func asciiQuoteID(id string) string {
+	if id == "" || strings.IndexFunc(id, func(r rune) bool {
+		return r == '"' || unicode.IsSpace(r) || !strconv.IsPrint(r)
+	}) >= 0 {
+		return strconv.Quote(id)
+	}
+	return id
+}
+
+// splitASCIIFields splits a line at white space like strings.Fields, but reads
+// Go string literals as written by asciiQuoteID, also after an attribute's '='
+func splitASCIIFields(line string) ([]string, error) {
+	var fields []string
+	for {
+		line = strings.TrimLeftFunc(line, unicode.IsSpace)
+		if line == "" {
+			return fields, nil
+		}
+
+		var field strings.Builder
+		for line != "" {
+			r, size := utf8.DecodeRuneInString(line)
+			if unicode.IsSpace(r) {
+				break
+			}
+			if r != '"' {
+				field.WriteString(line[:size])
+				line = line[size:]
+				continue
+			}
+			quoted, err := strconv.QuotedPrefix(line)
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted string in %q", line)
+			}
+			s, _ := strconv.Unquote(quoted)
+			field.WriteString(s)
+			line = line[len(quoted):]
+		}
+		fields = append(fields, field.String())
+	}
+}
@@ -0,0 +1,208 @@
+package bombahead
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	state := &GameState{
+		CurrentTick: 12,
+		MaxTicks:    300,
+		Field: Field{Width: 5, Height: 3, Cells: []CellType{
+			Wall, Wall, Wall, Wall, Wall,
+			Wall, Air, Box, Air, Air,
+			Wall, Air, Air, Air, Air,
+		}},
+		Players: []Player{
+			{ID: "p1", Pos: Position{X: 1, Y: 1}, Health: 3},
+			{ID: "p2", Pos: Position{X: 4, Y: 2}, Health: 2, Score: 5},
+		},
+		Bombs: []Bomb{
+			{Pos: Position{X: 3, Y: 1}, Fuse: 2},
+			{Pos: Position{X: 4, Y: 2}, Fuse: 1, Owner: "p2"},
+		},
+		Explosions: []Position{{X: 2, Y: 2}},
+	}
+	state.Me = &state.Players[1]
+
+	want := strings.Join([]string{
+		"tick 12 maxTicks 300",
+		"#####",
+		"#A+2.",
+		"#.*.b",
+		"A p1 hp=3 score=0",
+		"B p2 hp=2 score=5 me",
+		"bomb 4,2 fuse=1 owner=p2",
+		"",
+	}, "\n")
+	if got := state.Render(); got != want {
+		t.Fatalf("Render() =\n%s\nwant\n%s", got, want)
+	}
+	if got := state.String(); got != want {
+		t.Fatalf("String() =\n%s\nwant Render()", got)
+	}
+}
+
+func TestParseASCII_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	state := &GameState{
+		CurrentTick: 7,
+		Round:       2,
+		Field: Field{Width: 4, Height: 3, Cells: []CellType{
+			Air, Box, Air, Air,
+			Air, Wall, Air, Air,
+			Air, Air, Air, Air,
+		}},
+		Players: []Player{
+			{ID: "alice", Pos: Position{X: 0, Y: 0}, Health: 1, Score: 4},
+			{ID: "bob", Pos: Position{X: 2, Y: 1}, Health: 3, BombRange: 4, MaxBombs: 2, Speed: 1},
+			{ID: "carol", Pos: Position{X: 0, Y: 0}, Health: 0},
+		},
+		Bombs: []Bomb{
+			{Pos: Position{X: 2, Y: 0}, Fuse: 3},
+			{Pos: Position{X: 2, Y: 1}, Fuse: 12, Owner: "bob", Range: 4},
+			{Pos: Position{X: 0, Y: 2}, Fuse: 2, Range: 3},
+		},
+		Explosions: []Position{{X: 3, Y: 2}, {X: 0, Y: 0}},
+		Items:      []Item{{Pos: Position{X: 3, Y: 0}, Kind: PowerUpExtraBomb}},
+	}
+	me := state.Players[1]
+	state.Me = &me
+	state.Opponents = []Player{state.Players[0], state.Players[2]}
+
+	got, err := ParseASCII(state.Render())
+	if err != nil {
+		t.Fatalf("ParseASCII(Render()) error = %v\n%s", err, state.Render())
+	}
+	if !reflect.DeepEqual(got, state) {
+		t.Fatalf("ParseASCII(Render()) = %+v\nwant %+v\n%s", got, state, state.Render())
+	}
+}
+
+func TestParseASCII_RoundTripUnusualPlayers(t *testing.T) {
+	t.Parallel()
+
+	state := &GameState{Field: Field{Width: 3, Height: 1, Cells: []CellType{Air, Air, Air}}}
+	for i, id := range []string{"", "two words", `say "hi"`, "tab\there", "x=1"} {
+		state.Players = append(state.Players, Player{ID: id, Pos: Position{X: i % 3}, Health: 3})
+	}
+	for i := len(state.Players); i < 30; i++ {
+		state.Players = append(state.Players, Player{ID: fmt.Sprintf("p%d", i+1), Pos: Position{X: 1}, Health: 3})
+	}
+	state.Bombs = []Bomb{{Pos: Position{X: 2}, Fuse: 12, Owner: "two words"}}
+	me := state.Players[0]
+	state.Me = &me
+	state.Opponents = append([]Player(nil), state.Players[1:]...)
+
+	got, err := ParseASCII(state.Render())
+	if err != nil {
+		t.Fatalf("ParseASCII(Render()) error = %v\n%s", err, state.Render())
+	}
+	if !reflect.DeepEqual(got, state) {
+		t.Fatalf("ParseASCII(Render()) = %+v\nwant %+v\n%s", got, state, state.Render())
+	}
+}
+
+func TestAsciiPlayerLabel(t *testing.T) {
+	t.Parallel()
+
+	tests := map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"}
+	for i, want := range tests {
+		if got := asciiPlayerLabel(i); got != want {
+			t.Fatalf("asciiPlayerLabel(%d) = %q, want %q", i, got, want)
+		}
+		if got := asciiPlayerIndex(want); got != i {
+			t.Fatalf("asciiPlayerIndex(%q) = %d, want %d", want, got, i)
+		}
+	}
+}
+
+func TestParseASCII_HandWrittenFixture(t *testing.T) {
+	t.Parallel()
+
+	state := MustParseASCII(`
+		.....
+		.A#..
+		..1..
+		*...B
+	`)
+
+	if state.Field.Width != 5 || state.Field.Height != 4 || state.Field.CellAt(Position{X: 2, Y: 1}) != Wall {
+		t.Fatalf("Field = %+v, want 5x4 with a wall at 2,1", state.Field)
+	}
+	if state.Me == nil || state.Me.ID != "p1" || state.Me.Pos != (Position{X: 1, Y: 1}) || state.Me.Health != 3 {
+		t.Fatalf("Me = %+v, want p1 at 1,1 with 3 health", state.Me)
+	}
+	if len(state.Opponents) != 1 || state.Opponents[0].ID != "p2" || state.Opponents[0].Pos != (Position{X: 4, Y: 3}) {
+		t.Fatalf("Opponents = %+v, want p2 at 4,3", state.Opponents)
+	}
+	if !reflect.DeepEqual(state.Bombs, []Bomb{{Pos: Position{X: 2, Y: 2}, Fuse: 1}}) {
+		t.Fatalf("Bombs = %+v, want fuse 1 at 2,2", state.Bombs)
+	}
+	if !reflect.DeepEqual(state.Explosions, []Position{{X: 0, Y: 3}}) {
+		t.Fatalf("Explosions = %+v, want 0,3", state.Explosions)
+	}
+
+	h := NewGameHelpers(state)
+	if h.IsSafe(Position{X: 2, Y: 3}) || !h.IsSafe(Position{X: 1, Y: 1}) {
+		t.Fatal("expected blast lane below the bomb to be unsafe and the player to be safe")
+	}
+}
+
+func TestParseASCII_Legend(t *testing.T) {
+	t.Parallel()
+
+	state := MustParseASCII(`
+		tick 3
+		a..
+		...
+		A alice hp=2 score=1
+		B bob at=2,2 me
+		bomb 0,0 fuse=2 owner=alice
+		explosion 1,1
+	`)
+
+	if state.CurrentTick != 3 || state.Me.ID != "bob" || state.Me.Pos != (Position{X: 2, Y: 2}) {
+		t.Fatalf("state = tick %d, me %+v, want tick 3 and bob at 2,2", state.CurrentTick, state.Me)
+	}
+	if state.Players[0].Health != 2 || state.Players[0].Score != 1 {
+		t.Fatalf("alice = %+v, want hp 2 score 1", state.Players[0])
+	}
+	if !reflect.DeepEqual(state.Bombs, []Bomb{{Pos: Position{}, Fuse: 2, Owner: "alice"}}) {
+		t.Fatalf("Bombs = %+v, want alice's bomb under her with fuse 2", state.Bombs)
+	}
+	if !reflect.DeepEqual(state.Explosions, []Position{{X: 1, Y: 1}}) {
+		t.Fatalf("Explosions = %+v, want 1,1", state.Explosions)
+	}
+}
+
+func TestParseASCII_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"empty":          "",
+		"ragged rows":    "...\n..",
+		"unknown cell":   "..?",
+		"duplicate":      "A.A",
+		"gap in letters": "A.C",
+		"row after":      "...\nA p1\n...",
+		"unplaced":       "...\nA p1",
+		"bad attribute":  "A..\nA p1 wings=2",
+		"bad position":   "...\nbomb 1 fuse=2",
+		"bad header":     "tick x\n...",
+		"unknown legend": "...\ncrate 1,1",
+		"bad item":       "...\nitem 1,1 LASER",
+		"open quote":     "A..\nA \"p1 hp=3",
+	}
+	for name, input := range tests {
+		if _, err := ParseASCII(input); err == nil {
+			t.Fatalf("%s: ParseASCII(%q) error = nil", name, input)
+		}
+	}
+}
@@ -1,12 +1,12 @@
 package bombahead
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"os"
-
-	"github.com/N3moAhead/bombahead-go/internal/network"
+	"math/rand/v2"
 )
 
 const (
@@ -31,6 +31,8 @@ type errorPayload struct {
 type playerStatusUpdatePayload struct {
 	IsReady   bool   `json:"isReady"`
 	AuthToken string `json:"authToken,omitempty"`
+	// ClientID asks the server to resume the session of a previous welcome
+	ClientID string `json:"clientId,omitempty"`
 }
 
 type classicInputPayload struct {
@@ -38,10 +40,16 @@ type classicInputPayload struct {
 }
 
 type classicStatePayload struct {
-	Players    []Player   `json:"players"`
-	Field      fieldWire  `json:"field"`
-	Bombs      []Bomb     `json:"bombs"`
-	Explosions []Position `json:"explosions"`
+	// Tick and CurrentTick are both accepted, servers use either name
+	Tick        *int       `json:"tick"`
+	CurrentTick *int       `json:"currentTick"`
+	Round       int        `json:"round"`
+	MaxTicks    int        `json:"maxTicks"`
+	Players     []Player   `json:"players"`
+	Field       fieldWire  `json:"field"`
+	Bombs       []Bomb     `json:"bombs"`
+	Explosions  []Position `json:"explosions"`
+	Items       []Item     `json:"items"`
 }
 
 type fieldWire struct {
@@ -53,121 +61,297 @@ type fieldWire struct {
 // Run starts the bot and connects to the game server
 // It blocks until the connection closes or an unrecoverable error occurs
 func Run(userBot Bot) {
-	wsURL := os.Getenv("BOMBAHEAD_WS_URL")
-	if wsURL == "" {
-		wsURL = "ws://localhost:8038/ws"
+	err := RunContext(context.Background(), userBot)
+	switch {
+	case err == nil:
+	case errors.Is(err, ErrDial), errors.Is(err, ErrAuthRejected):
+		log.Fatalf("Failed to connect: %v", err)
+	default:
+		log.Printf("Bot stopped: %v", err)
 	}
+}
 
-	token := os.Getenv("BOMBAHEAD_TOKEN")
-	if token == "" {
-		token = os.Getenv("BOMBERMAN_CLIENT_AUTH_TOKEN")
-	}
-	if token == "" {
-		token = "dev-token-local"
+// RunContext starts the bot and connects to the game server
+// The connection is opened with WebSocketDialer unless WithDialer or WithTransport is given.
+// It blocks until ctx is cancelled, the connection closes or an unrecoverable error occurs.
+// Cancelling ctx closes the connection and returns ctx.Err(); every other failure
+// wraps one of ErrDial, ErrAuthRejected, ErrProtocol, ErrServerClosed or ErrConnectionLost.
+// With WithReconnect, dial and connection failures are retried before they are returned
+func RunContext(ctx context.Context, userBot Bot, opts ...Option) error {
+	cfg := newConfig(opts)
+	r := &runner{bot: userBot, cfg: cfg, parser: stateParser{strict: cfg.strict}}
+	return r.run(ctx)
+}
+
+// runner holds the state of one client loop
+type runner struct {
+	bot  Bot
+	cfg  config
+	myID string
+
+	stats MoveStats
+	// pending receives the result of a bot call that missed its deadline
+	pending chan botResult
+	// lastState is the most recent state of the current game, reported in GameEnd
+	lastState *GameState
+	parser    stateParser
+	// anomalyTotals counts protocol anomalies accepted in lenient mode
+	anomalyTotals map[AnomalyKind]int
+}
+
+func (r *runner) run(ctx context.Context) error {
+	attempt := 0
+	for {
+		connected, err := r.session(ctx, attempt > 0)
+		if connected {
+			attempt = 0
+		}
+		if !r.retryable(ctx, err) {
+			return err
+		}
+		if connected {
+			r.notifyReconnect(ReconnectEvent{Kind: ReconnectDisconnected, Err: err, ClientID: r.myID})
+		}
+
+		attempt++
+		policy := *r.cfg.reconnect
+		if policy.MaxAttempts > 0 && attempt > policy.MaxAttempts {
+			log.Printf("Giving up after %d reconnect attempts: %v", policy.MaxAttempts, err)
+			r.notifyReconnect(ReconnectEvent{Kind: ReconnectGaveUp, Attempt: attempt - 1, Err: err, ClientID: r.myID})
+			return err
+		}
+
+		delay := policy.backoff(attempt, rand.Float64())
+		log.Printf("Connection failed (%v), reconnecting in %s (attempt %d)", err, delay, attempt)
+		r.notifyReconnect(ReconnectEvent{Kind: ReconnectAttempting, Attempt: attempt, Delay: delay, Err: err, ClientID: r.myID})
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
+		}
 	}
+}
 
-	log.Printf("Connecting to %s...", wsURL)
+// session dials the server once and serves the connection until it ends
+// connected reports whether the dial succeeded
+func (r *runner) session(ctx context.Context, reconnecting bool) (connected bool, err error) {
+	log.Printf("Connecting to %s...", r.cfg.url)
 
-	client, err := network.Connect(wsURL, token)
+	client, err := r.cfg.dial(ctx, r.cfg.url, r.cfg.token)
 	if err != nil {
-		log.Fatalf("Failed to connect: %v", err)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return false, ctxErr
+		}
+		return false, classifyDialError(err)
+	}
+
+	if r.cfg.recorder != nil {
+		client = recordingTransport{Transport: client, rec: r.cfg.recorder}
 	}
-	defer client.Close()
 
-	if err := client.Send(msgPlayerStatusUpdate, playerStatusUpdatePayload{IsReady: true, AuthToken: token}); err != nil {
-		log.Fatalf("Failed to send initial ready state: %v", err)
+	if reconnecting {
+		log.Printf("Reconnected to %s", r.cfg.url)
+		r.notifyReconnect(ReconnectEvent{Kind: ReconnectSucceeded, ClientID: r.myID})
 	}
 
-	var myID string
+	return true, r.serve(ctx, client)
+}
+
+// serve drives the message loop on an established connection
+func (r *runner) serve(ctx context.Context, client Transport) error {
+	defer client.Close()
+	stop := context.AfterFunc(ctx, func() { client.Close() })
+	defer stop()
+
+	// myID is still set from a previous connection when reconnecting
+	ready := playerStatusUpdatePayload{IsReady: true, AuthToken: r.cfg.token, ClientID: r.myID}
+	if err := client.Send(msgPlayerStatusUpdate, ready); err != nil {
+		return classifyConnError(ctx, fmt.Errorf("send initial ready state: %w", err))
+	}
 
 	for {
 		msg, err := client.ReadMessage()
 		if err != nil {
-			log.Printf("Error reading message: %v", err)
-			return
+			return classifyConnError(ctx, fmt.Errorf("read message: %w", err))
 		}
 
-		switch msg.Type {
-		case msgWelcome:
-			var payload welcomePayload
-			if err := json.Unmarshal(msg.Payload, &payload); err != nil {
-				log.Printf("Failed to parse welcome payload: %v", err)
-				continue
-			}
-			myID = payload.ClientID
-			log.Printf("Connected as %s", myID)
+		if err := r.handle(ctx, client, msg); err != nil {
+			return classifyConnError(ctx, err)
+		}
+	}
+}
 
-		case msgUpdateLobby:
-			// Intentionally ignored for bot decision logic
-			continue
+// handle processes one server message
+// Malformed payloads are logged and skipped; only transport failures are returned
+func (r *runner) handle(ctx context.Context, client Transport, msg *Message) error {
+	switch msg.Type {
+	case msgWelcome:
+		var payload welcomePayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			log.Printf("Failed to parse welcome payload: %v", err)
+			return nil
+		}
+		r.myID = payload.ClientID
+		log.Printf("Connected as %s", r.myID)
 
-		case msgServerError:
-			var payload errorPayload
-			if err := json.Unmarshal(msg.Payload, &payload); err != nil {
-				log.Printf("Server error (unparsed payload): %s", string(msg.Payload))
-				continue
-			}
+	case msgUpdateLobby:
+		l, ok := r.bot.(LobbyListener)
+		if !ok {
+			return nil
+		}
+		var lobby Lobby
+		if err := decodeOptionalPayload(msg.Payload, &lobby); err != nil {
+			log.Printf("Failed to parse lobby payload: %v", err)
+			return nil
+		}
+		l.OnLobbyUpdate(lobby)
+
+	case msgServerError:
+		var payload errorPayload
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			log.Printf("Server error (unparsed payload): %s", string(msg.Payload))
+			payload.Message = string(msg.Payload)
+		} else {
 			log.Printf("Server error: %s", payload.Message)
+		}
+		if l, ok := r.bot.(ServerErrorListener); ok {
+			l.OnServerError(payload.Message)
+		}
 
-		case msgGameStart:
-			log.Printf("Game started")
-
-		case msgBackToLobby:
-			if err := client.Send(msgPlayerStatusUpdate, playerStatusUpdatePayload{IsReady: true}); err != nil {
-				log.Printf("Failed to re-ready in lobby: %v", err)
-				return
+	case msgGameStart:
+		log.Printf("Game started")
+		r.lastState = nil
+		r.parser.reset()
+		r.waitPending()
+		if l, ok := r.bot.(GameStartListener); ok {
+			var start GameStart
+			if err := decodeOptionalPayload(msg.Payload, &start); err != nil {
+				log.Printf("Failed to parse game start payload: %v", err)
 			}
+			l.OnGameStart(start)
+		}
 
-		case msgClassicState:
-			state, err := parseClassicState(msg.Payload, myID)
-			if err != nil {
-				log.Printf("Failed to parse classic state: %v", err)
-				continue
+	case msgBackToLobby:
+		r.waitPending()
+		if l, ok := r.bot.(GameEndListener); ok {
+			var end GameEnd
+			if err := decodeOptionalPayload(msg.Payload, &end); err != nil {
+				log.Printf("Failed to parse game end payload: %v", err)
 			}
+			end.LastState = r.lastState
+			l.OnGameEnd(end)
+		}
+		r.lastState = nil
+		r.parser.reset()
 
-			helpers := NewGameHelpers(state)
-			action := userBot.GetNextMove(state, helpers)
+		if err := client.Send(msgPlayerStatusUpdate, playerStatusUpdatePayload{IsReady: true}); err != nil {
+			return fmt.Errorf("re-ready in lobby: %w", err)
+		}
 
-			if err := client.Send(msgClassicInput, classicInputPayload{Move: action}); err != nil {
-				log.Printf("Failed to send action: %v", err)
-				return
-			}
+	case msgClassicState:
+		state, anomalies, err := r.parser.parse(msg.Payload, r.myID)
+		if err != nil {
+			log.Printf("Failed to parse classic state: %v", err)
+			return nil
+		}
+		if len(anomalies) > 0 {
+			r.reportAnomalies(state.CurrentTick, anomalies)
+		}
+		r.lastState = state
 
-		default:
-			log.Printf("Ignoring message type %q", msg.Type)
+		action := r.decide(ctx, turn{
+			state:    state,
+			helpers:  NewGameHelpers(state),
+			payload:  msg.Payload,
+			clientID: r.myID,
+		})
+
+		if err := client.Send(msgClassicInput, classicInputPayload{Move: action}); err != nil {
+			return fmt.Errorf("send action: %w", err)
 		}
+		r.moveSent()
+
+	default:
+		log.Printf("Ignoring message type %q", msg.Type)
 	}
+
+	return nil
 }
 
-func parseClassicState(data []byte, myID string) (*GameState, error) {
+// stateParser turns classic_state payloads into GameStates
+// It counts ticks itself for servers that do not send one
+type stateParser struct {
+	// strict rejects states with anomalies instead of repairing them
+	strict bool
+	// nextTick is the tick assigned to the next state without a wire tick
+	nextTick int
+}
+
+// reset restarts the client-side tick counter, called when a game starts or ends
+func (p *stateParser) reset() {
+	p.nextTick = 0
+}
+
+// parse decodes one payload and returns the anomalies found in it
+// In strict mode any anomaly makes parse fail with a *ProtocolError
+func (p *stateParser) parse(data []byte, myID string) (*GameState, []Anomaly, error) {
 	var payload classicStatePayload
 	if err := json.Unmarshal(data, &payload); err != nil {
-		return nil, fmt.Errorf("unmarshal classic state: %w", err)
+		return nil, nil, fmt.Errorf("unmarshal classic state: %w", err)
+	}
+
+	anomalies := validatePayload(&payload, myID)
+
+	if !validFieldSize(payload.Field.Width, payload.Field.Height) {
+		if p.strict {
+			return nil, anomalies, &ProtocolError{Anomalies: anomalies}
+		}
+		// The size was reported as an anomaly, the state continues with an empty field
+		payload.Field.Width, payload.Field.Height = 0, 0
 	}
 
 	cells := make([]CellType, payload.Field.Width*payload.Field.Height)
 	for i := 0; i < len(cells) && i < len(payload.Field.Field); i++ {
-		cell, err := decodeCell(payload.Field.Field[i])
+		cell, known, err := decodeCellChecked(payload.Field.Field[i])
 		if err != nil {
-			return nil, fmt.Errorf("decode field cell %d: %w", i, err)
+			return nil, nil, fmt.Errorf("decode field cell %d: %w", i, err)
+		}
+		if !known {
+			anomalies = append(anomalies, Anomaly{
+				Kind:   AnomalyUnknownCell,
+				Detail: fmt.Sprintf("cell %d has unknown code %s", i, string(payload.Field.Field[i])),
+			})
 		}
 		cells[i] = cell
 	}
 
+	if p.strict && len(anomalies) > 0 {
+		return nil, anomalies, &ProtocolError{Anomalies: anomalies}
+	}
+
 	state := &GameState{
-		Players:    payload.Players,
-		Field:      Field{Width: payload.Field.Width, Height: payload.Field.Height, Cells: cells},
-		Bombs:      payload.Bombs,
-		Explosions: payload.Explosions,
+		CurrentTick: p.nextTick,
+		Round:       payload.Round,
+		MaxTicks:    payload.MaxTicks,
+		Players:     append(make([]Player, 0, len(payload.Players)), payload.Players...),
+		Field:       Field{Width: payload.Field.Width, Height: payload.Field.Height, Cells: cells},
+		Bombs:       payload.Bombs,
+		Explosions:  payload.Explosions,
+		Items:       payload.Items,
+	}
+	switch {
+	case payload.Tick != nil:
+		state.CurrentTick = *payload.Tick
+	case payload.CurrentTick != nil:
+		state.CurrentTick = *payload.CurrentTick
 	}
+	p.nextTick = state.CurrentTick + 1
 
-	for _, p := range payload.Players {
-		if p.ID == myID {
-			player := p
+	for _, pl := range payload.Players {
+		if pl.ID == myID {
+			player := pl
 			state.Me = &player
 			continue
 		}
-		state.Opponents = append(state.Opponents, p)
+		state.Opponents = append(state.Opponents, pl)
 	}
 
 	if state.Me == nil && len(payload.Players) > 0 {
@@ -179,28 +363,44 @@ func parseClassicState(data []byte, myID string) (*GameState, error) {
 		}
 	}
 
-	return state, nil
+	return state, anomalies, nil
+}
+
+// ParseClassicState decodes one classic_state payload as seen by the player myID
+// Tools use it to read recorded or spectated states; without tick history CurrentTick is the tick the server sent
+func ParseClassicState(data []byte, myID string) (*GameState, error) {
+	var p stateParser
+	state, _, err := p.parse(data, myID)
+	return state, err
 }
 
 func decodeCell(raw json.RawMessage) (CellType, error) {
+	cell, _, err := decodeCellChecked(raw)
+	return cell, err
+}
+
+// decodeCellChecked decodes a cell and reports whether its code is known
+// Unknown numeric codes decode to Air, unknown strings are passed through
+func decodeCellChecked(raw json.RawMessage) (CellType, bool, error) {
 	var s string
 	if err := json.Unmarshal(raw, &s); err == nil {
-		return CellType(s), nil
+		cell := CellType(s)
+		return cell, cell == Air || cell == Wall || cell == Box, nil
 	}
 
 	var n int
 	if err := json.Unmarshal(raw, &n); err == nil {
 		switch n {
 		case 0:
-			return Wall, nil
+			return Wall, true, nil
 		case 1:
-			return Air, nil
+			return Air, true, nil
 		case 2:
-			return Box, nil
+			return Box, true, nil
 		default:
-			return Air, nil
+			return Air, false, nil
 		}
 	}
 
-	return Air, fmt.Errorf("unsupported cell encoding: %s", string(raw))
+	return Air, false, fmt.Errorf("unsupported cell encoding: %s", string(raw))
 }
@@ -1,8 +1,18 @@
 package bombahead
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/N3moAhead/bombahead-go/internal/network"
+	"github.com/gorilla/websocket"
 )
 
 func TestDecodeCell_StringAndNumericEncodings(t *testing.T) {
@@ -61,9 +71,9 @@ func TestParseClassicState_AssignsMeAndOpponents(t *testing.T) {
 		"explosions":[{"x":0,"y":1}]
 	}`)
 
-	state, err := parseClassicState(payload, "p2")
+	state, err := ParseClassicState(payload, "p2")
 	if err != nil {
-		t.Fatalf("parseClassicState() unexpected error: %v", err)
+		t.Fatalf("ParseClassicState() unexpected error: %v", err)
 	}
 	if state.Me == nil || state.Me.ID != "p2" {
 		t.Fatalf("Me not assigned correctly: %+v", state.Me)
@@ -92,9 +102,9 @@ func TestParseClassicState_FallbackWithoutWelcome(t *testing.T) {
 		"explosions":[]
 	}`)
 
-	state, err := parseClassicState(payload, "unknown-id")
+	state, err := ParseClassicState(payload, "unknown-id")
 	if err != nil {
-		t.Fatalf("parseClassicState() unexpected error: %v", err)
+		t.Fatalf("ParseClassicState() unexpected error: %v", err)
 	}
 	if state.Me == nil || state.Me.ID != "first" {
 		t.Fatalf("fallback Me = %+v, want first player", state.Me)
@@ -107,8 +117,262 @@ func TestParseClassicState_FallbackWithoutWelcome(t *testing.T) {
 func TestParseClassicState_InvalidPayload(t *testing.T) {
 	t.Parallel()
 
-	_, err := parseClassicState([]byte(`{"players":[`), "p1")
+	_, err := ParseClassicState([]byte(`{"players":[`), "p1")
 	if err == nil {
-		t.Fatal("parseClassicState() expected error for invalid JSON, got nil")
+		t.Fatal("ParseClassicState() expected error for invalid JSON, got nil")
+	}
+}
+
+// startTestServer runs handler for every websocket connection and returns the ws:// URL
+func startTestServer(t *testing.T, handler func(conn *websocket.Conn)) string {
+	t.Helper()
+
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade failed: %v", err)
+			return
+		}
+		defer conn.Close()
+		handler(conn)
+	}))
+	t.Cleanup(server.Close)
+
+	return "ws" + strings.TrimPrefix(server.URL, "http")
+}
+
+func writeTestMessage(t *testing.T, conn *websocket.Conn, msgType string, payload any) {
+	t.Helper()
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Errorf("marshal payload: %v", err)
+		return
+	}
+	envelope, _ := json.Marshal(network.Message{Type: msgType, Payload: data})
+	if err := conn.WriteMessage(websocket.TextMessage, envelope); err != nil {
+		t.Errorf("server write failed: %v", err)
+	}
+}
+
+func readTestMessage(t *testing.T, conn *websocket.Conn) network.Message {
+	t.Helper()
+
+	var msg network.Message
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Errorf("server read failed: %v", err)
+		return msg
+	}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		t.Errorf("server unmarshal failed: %v", err)
+	}
+	return msg
+}
+
+type constantBot struct {
+	action Action
+}
+
+func (b constantBot) GetNextMove(*GameState, *GameHelpers) Action {
+	return b.action
+}
+
+func TestRunContext_DialFailure(t *testing.T) {
+	t.Parallel()
+
+	err := RunContext(context.Background(), constantBot{}, WithURL("ws://127.0.0.1:1/ws"))
+	if !errors.Is(err, ErrDial) {
+		t.Fatalf("RunContext() error = %v, want ErrDial", err)
+	}
+}
+
+func TestRunContext_AuthRejected(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad token", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	err := RunContext(context.Background(), constantBot{}, WithURL("ws"+strings.TrimPrefix(server.URL, "http")))
+	if !errors.Is(err, ErrAuthRejected) {
+		t.Fatalf("RunContext() error = %v, want ErrAuthRejected", err)
+	}
+}
+
+func TestRunContext_PlaysAndReturnsServerClosed(t *testing.T) {
+	t.Parallel()
+
+	received := make(chan network.Message, 4)
+	url := startTestServer(t, func(conn *websocket.Conn) {
+		received <- readTestMessage(t, conn)
+		writeTestMessage(t, conn, msgWelcome, welcomePayload{ClientID: "p1"})
+		writeTestMessage(t, conn, msgClassicState, map[string]any{
+			"players": []Player{{ID: "p1"}},
+			"field":   map[string]any{"width": 1, "height": 1, "field": []string{"AIR"}},
+		})
+		received <- readTestMessage(t, conn)
+		_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "bye"))
+	})
+
+	err := RunContext(context.Background(), constantBot{action: PlaceBomb}, WithURL(url), WithToken("secret"))
+	if !errors.Is(err, ErrServerClosed) {
+		t.Fatalf("RunContext() error = %v, want ErrServerClosed", err)
+	}
+
+	ready := <-received
+	var status playerStatusUpdatePayload
+	if err := json.Unmarshal(ready.Payload, &status); err != nil {
+		t.Fatalf("unmarshal ready payload: %v", err)
+	}
+	if ready.Type != msgPlayerStatusUpdate || !status.IsReady || status.AuthToken != "secret" {
+		t.Fatalf("first message = %s %+v, want ready with token", ready.Type, status)
+	}
+
+	input := <-received
+	var move classicInputPayload
+	if err := json.Unmarshal(input.Payload, &move); err != nil {
+		t.Fatalf("unmarshal input payload: %v", err)
+	}
+	if input.Type != msgClassicInput || move.Move != PlaceBomb {
+		t.Fatalf("second message = %s %+v, want classic_input place_bomb", input.Type, move)
+	}
+}
+
+func TestRunContext_ProtocolError(t *testing.T) {
+	t.Parallel()
+
+	url := startTestServer(t, func(conn *websocket.Conn) {
+		readTestMessage(t, conn)
+		_ = conn.WriteMessage(websocket.TextMessage, []byte("not json"))
+		_, _, _ = conn.ReadMessage()
+	})
+
+	err := RunContext(context.Background(), constantBot{}, WithURL(url))
+	if !errors.Is(err, ErrProtocol) {
+		t.Fatalf("RunContext() error = %v, want ErrProtocol", err)
+	}
+}
+
+func TestRunContext_Cancellation(t *testing.T) {
+	t.Parallel()
+
+	url := startTestServer(t, func(conn *websocket.Conn) {
+		readTestMessage(t, conn)
+		_, _, _ = conn.ReadMessage()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := RunContext(ctx, constantBot{}, WithURL(url))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("RunContext() error = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestParseClassicState_DecodesTickAndMetadata(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		raw  string
+		want int
+	}{
+		{name: "tick", raw: `{"tick":12,"round":2,"maxTicks":300,"players":[],"field":{"width":0,"height":0,"field":[]}}`, want: 12},
+		{name: "currentTick", raw: `{"currentTick":7,"round":2,"maxTicks":300,"players":[],"field":{"width":0,"height":0,"field":[]}}`, want: 7},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			state, err := ParseClassicState([]byte(tc.raw), "p1")
+			if err != nil {
+				t.Fatalf("ParseClassicState() unexpected error: %v", err)
+			}
+			if state.CurrentTick != tc.want || state.Round != 2 || state.MaxTicks != 300 {
+				t.Fatalf("tick/round/maxTicks = %d/%d/%d, want %d/2/300", state.CurrentTick, state.Round, state.MaxTicks, tc.want)
+			}
+			if state.Players == nil {
+				t.Fatal("Players = nil, want empty slice")
+			}
+		})
+	}
+}
+
+func TestStateParser_FallbackTickCounter(t *testing.T) {
+	t.Parallel()
+
+	noTick := []byte(`{"players":[{"id":"p1"}],"field":{"width":1,"height":1,"field":["AIR"]}}`)
+	withTick := []byte(`{"tick":40,"players":[{"id":"p1"}],"field":{"width":1,"height":1,"field":["AIR"]}}`)
+
+	var p stateParser
+	for i, data := range [][]byte{noTick, noTick, withTick, noTick} {
+		state, _, err := p.parse(data, "p1")
+		if err != nil {
+			t.Fatalf("parse() unexpected error: %v", err)
+		}
+		want := []int{0, 1, 40, 41}[i]
+		if state.CurrentTick != want {
+			t.Fatalf("state %d CurrentTick = %d, want %d", i, state.CurrentTick, want)
+		}
+	}
+
+	p.reset()
+	state, _, err := p.parse(noTick, "p1")
+	if err != nil {
+		t.Fatalf("parse() unexpected error: %v", err)
+	}
+	if state.CurrentTick != 0 {
+		t.Fatalf("CurrentTick after reset = %d, want 0", state.CurrentTick)
+	}
+}
+
+func TestParseClassicState_PlayersIncludeEveryone(t *testing.T) {
+	t.Parallel()
+
+	payload := []byte(`{
+		"players":[{"id":"a"},{"id":"b"},{"id":"c"}],
+		"field":{"width":1,"height":1,"field":["AIR"]}
+	}`)
+	state, err := ParseClassicState(payload, "b")
+	if err != nil {
+		t.Fatalf("ParseClassicState() unexpected error: %v", err)
+	}
+	if len(state.Players) != 3 || state.Players[0].ID != "a" || state.Players[2].ID != "c" {
+		t.Fatalf("Players = %+v, want a, b, c", state.Players)
+	}
+
+	state.Opponents[0].Health = 99
+	if state.Players[0].Health == 99 {
+		t.Fatal("Players must not share storage with Opponents")
+	}
+}
+
+func TestParseClassicState_DecodesPowerUps(t *testing.T) {
+	t.Parallel()
+
+	payload := []byte(`{
+		"players":[{"id":"p1","bombRange":3,"maxBombs":2,"speed":1}],
+		"field":{"width":2,"height":1,"field":["AIR","AIR"]},
+		"bombs":[{"pos":{"x":0,"y":0},"fuse":2,"owner":"p1","range":3}],
+		"items":[{"pos":{"x":1,"y":0},"kind":"EXTRA_BOMB"}]
+	}`)
+	state, err := ParseClassicState(payload, "p1")
+	if err != nil {
+		t.Fatalf("ParseClassicState() unexpected error: %v", err)
+	}
+	if me := state.Me; me.BombRange != 3 || me.MaxBombs != 2 || me.Speed != 1 {
+		t.Fatalf("Me = %+v, want bombRange 3, maxBombs 2, speed 1", *me)
+	}
+	if b := state.Bombs[0]; b.Owner != "p1" || b.Range != 3 {
+		t.Fatalf("Bombs[0] = %+v, want owner p1 and range 3", b)
+	}
+	want := []Item{{Pos: Position{X: 1, Y: 0}, Kind: PowerUpExtraBomb}}
+	if !reflect.DeepEqual(state.Items, want) {
+		t.Fatalf("Items = %v, want %v", state.Items, want)
 	}
 }
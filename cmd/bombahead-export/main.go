@@ -0,0 +1,133 @@
+// Command bombahead-export draws a recorded game or a single state as PNG or GIF
+//
+// The output format follows the extension of -out:
+//
+//	go run ./cmd/bombahead-export -replay game.jsonl.gz -out game.gif
+//	go run ./cmd/bombahead-export -replay game.jsonl.gz -tick 42 -out tick42.png
+//	go run ./cmd/bombahead-export -ascii fixture.txt -out fixture.png
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	bombahead "github.com/N3moAhead/bombahead-go"
+	"github.com/N3moAhead/bombahead-go/picture"
+)
+
+func main() {
+	var replay, ascii, out string
+	var tick, from, to int
+	var delay time.Duration
+	opts := picture.Options{}
+	flag.StringVar(&replay, "replay", "", "replay file written by bombahead.Recorder")
+	flag.StringVar(&ascii, "ascii", "", "file with a state in the format of GameState.Render")
+	flag.StringVar(&out, "out", "", "output file ending in .png or .gif")
+	flag.IntVar(&tick, "tick", -1, "tick to draw as PNG (default: the last state)")
+	flag.IntVar(&from, "from", 0, "first tick of the GIF")
+	flag.IntVar(&to, "to", -1, "last tick of the GIF (default: the last state)")
+	flag.DurationVar(&delay, "delay", 200*time.Millisecond, "time per GIF frame")
+	flag.IntVar(&opts.CellSize, "cell", 24, "cell size in pixels")
+	flag.BoolVar(&opts.HideDanger, "no-danger", false, "do not shade cells about to be hit")
+	flag.Parse()
+
+	if err := run(replay, ascii, out, tick, from, to, delay, opts); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(replay, ascii, out string, tick, from, to int, delay time.Duration, opts picture.Options) error {
+	if out == "" || (replay == "") == (ascii == "") {
+		return errors.New("need -out and exactly one of -replay or -ascii")
+	}
+
+	states, err := loadStates(replay, ascii)
+	if err != nil {
+		return err
+	}
+
+	// write is chosen and checked before the file is created, so invalid flags leave nothing behind
+	var write func(io.Writer) error
+	switch strings.ToLower(filepath.Ext(out)) {
+	case ".png":
+		state := states[len(states)-1]
+		if tick >= 0 {
+			if state = findTick(states, tick); state == nil {
+				return fmt.Errorf("no state with tick %d", tick)
+			}
+		}
+		write = func(w io.Writer) error { return picture.WritePNG(w, state, opts) }
+	case ".gif":
+		var frames []*bombahead.GameState
+		for _, s := range states {
+			if s.CurrentTick >= from && (to < 0 || s.CurrentTick <= to) {
+				frames = append(frames, s)
+			}
+		}
+		if len(frames) == 0 {
+			return fmt.Errorf("no states between tick %d and %d", from, to)
+		}
+		write = func(w io.Writer) error { return picture.WriteGIF(w, frames, delay, opts) }
+	default:
+		return fmt.Errorf("unknown output format %q, want .png or .gif", filepath.Ext(out))
+	}
+
+	f, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	err = write(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// A partly written image is of no use, so do not leave it behind
+		_ = os.Remove(out)
+		return err
+	}
+	return nil
+}
+
+func loadStates(replay, ascii string) ([]*bombahead.GameState, error) {
+	if ascii != "" {
+		data, err := os.ReadFile(ascii)
+		if err != nil {
+			return nil, err
+		}
+		state, err := bombahead.ParseASCII(string(data))
+		if err != nil {
+			return nil, err
+		}
+		return []*bombahead.GameState{state}, nil
+	}
+
+	rec, err := bombahead.LoadReplay(replay)
+	if err != nil {
+		return nil, err
+	}
+	states, err := rec.States()
+	if err != nil {
+		return nil, err
+	}
+	if len(states) == 0 {
+		return nil, errors.New("replay has no states")
+	}
+	return states, nil
+}
+
+// findTick returns the last state with the tick, as replays with several games repeat ticks
+func findTick(states []*bombahead.GameState, tick int) *bombahead.GameState {
+	for i := len(states) - 1; i >= 0; i-- {
+		if states[i].CurrentTick == tick {
+			return states[i]
+		}
+	}
+	return nil
+}
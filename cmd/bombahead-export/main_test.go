@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"image/png"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/N3moAhead/bombahead-go/picture"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	ascii := filepath.Join(dir, "state.txt")
+	if err := os.WriteFile(ascii, []byte("tick 3\n#####\n#A.B#\n#####\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		out     string
+		tick    int
+		wantErr bool
+	}{
+		{name: "png", out: "state.png", tick: -1},
+		{name: "missing tick", out: "missing.png", tick: 9, wantErr: true},
+		{name: "unknown format", out: "state.bmp", tick: -1, wantErr: true},
+	}
+	for _, tt := range tests {
+		out := filepath.Join(dir, tt.out)
+		err := run("", ascii, out, tt.tick, 0, -1, 0, picture.Options{})
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%s: run() error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+		if tt.wantErr {
+			if _, err := os.Stat(out); !errors.Is(err, fs.ErrNotExist) {
+				t.Fatalf("%s: output file left behind after an error (stat error %v)", tt.name, err)
+			}
+			continue
+		}
+
+		f, err := os.Open(out)
+		if err != nil {
+			t.Fatalf("%s: open output: %v", tt.name, err)
+		}
+		_, err = png.Decode(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: output is not a PNG: %v", tt.name, err)
+		}
+	}
+}
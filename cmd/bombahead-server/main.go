@@ -0,0 +1,46 @@
+// Command bombahead-server runs a local stand-in for the Bombahead game server
+//
+// Start it, then point bots at ws://localhost:8038/ws (the SDK default):
+//
+//	go run ./cmd/bombahead-server -players 2 -tick 100ms
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/N3moAhead/bombahead-go/server"
+)
+
+func main() {
+	def := server.DefaultConfig()
+	cfg := server.Config{}
+	flag.StringVar(&cfg.Addr, "addr", def.Addr, "listen address")
+	flag.StringVar(&cfg.Token, "token", "", "only accept this bearer token (default: accept any)")
+	flag.IntVar(&cfg.PlayersPerMatch, "players", def.PlayersPerMatch, "ready players needed to start a match")
+	flag.DurationVar(&cfg.TickInterval, "tick", def.TickInterval, "time between ticks")
+	flag.IntVar(&cfg.MaxTicks, "max-ticks", def.MaxTicks, "ticks before a match ends undecided")
+	flag.IntVar(&cfg.Width, "width", def.Width, "board width")
+	flag.IntVar(&cfg.Height, "height", def.Height, "board height")
+	flag.Float64Var(&cfg.BoxDensity, "boxes", def.BoxDensity, "chance that a free cell starts with a box")
+	flag.IntVar(&cfg.BombFuse, "fuse", def.BombFuse, "bomb fuse in ticks")
+	flag.IntVar(&cfg.BombRange, "range", def.BombRange, "bomb blast range")
+	flag.IntVar(&cfg.StartHealth, "health", def.StartHealth, "player start health")
+	flag.IntVar(&cfg.MaxBombs, "bombs", def.MaxBombs, "bombs a player may have on the field at once")
+	flag.IntVar(&cfg.BoxScore, "box-score", def.BoxScore, "score for destroying a box")
+	flag.IntVar(&cfg.HitScore, "hit-score", def.HitScore, "score for hitting an opponent")
+	flag.Uint64Var(&cfg.Seed, "seed", 0, "board seed (default: random)")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	srv := server.New(cfg)
+	log.Printf("Board seed %d", srv.Config().Seed)
+	if err := srv.ListenAndServe(ctx); err != nil && ctx.Err() == nil {
+		log.Fatalf("Server failed: %v", err)
+	}
+}
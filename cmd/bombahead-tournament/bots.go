@@ -0,0 +1,104 @@
+package main
+
+import (
+	"math/rand/v2"
+
+	bombahead "github.com/N3moAhead/bombahead-go"
+	"github.com/N3moAhead/bombahead-go/tournament"
+)
+
+// The built-in bots give candidate bots something to measure against
+// Register your own bots here, or copy this command and register them in its place
+func init() {
+	tournament.Register("idle", func() bombahead.Bot { return idleBot{} })
+	tournament.Register("random", func() bombahead.Bot { return &randomBot{rnd: rand.New(rand.NewPCG(1, 2))} })
+	tournament.Register("boxer", func() bombahead.Bot { return boxerBot{} })
+	tournament.Register("hunter", func() bombahead.Bot { return hunterBot{} })
+}
+
+// idleBot never moves
+type idleBot struct{}
+
+func (idleBot) GetNextMove(*bombahead.GameState, *bombahead.GameHelpers) bombahead.Action {
+	return bombahead.DoNothing
+}
+
+// randomBot escapes danger and otherwise picks a random action
+type randomBot struct {
+	rnd *rand.Rand
+}
+
+var randomActions = []bombahead.Action{
+	bombahead.DoNothing, bombahead.MoveUp, bombahead.MoveDown,
+	bombahead.MoveLeft, bombahead.MoveRight, bombahead.PlaceBomb,
+}
+
+func (b *randomBot) GetNextMove(state *bombahead.GameState, h *bombahead.GameHelpers) bombahead.Action {
+	if state.Me == nil {
+		return bombahead.DoNothing
+	}
+	if !h.IsSafe(state.Me.Pos) {
+		return bombahead.SafeFallback(state, h)
+	}
+	return randomActions[b.rnd.IntN(len(randomActions))]
+}
+
+// boxerBot is the README example bot: it escapes danger and bombs the nearest box
+type boxerBot struct{}
+
+func (boxerBot) GetNextMove(state *bombahead.GameState, h *bombahead.GameHelpers) bombahead.Action {
+	if state.Me == nil {
+		return bombahead.DoNothing
+	}
+	me := state.Me.Pos
+	if !h.IsSafe(me) {
+		return bombahead.SafeFallback(state, h)
+	}
+	if box, ok := h.FindNearestBox(me); ok {
+		if me.DistanceTo(box) == 1 {
+			if h.CanSafelyPlaceBomb(me) {
+				return bombahead.PlaceBomb
+			}
+			return bombahead.DoNothing
+		}
+		return h.GetNextActionTowards(me, box)
+	}
+	return bombahead.DoNothing
+}
+
+// hunterBot walks towards the nearest living opponent and bombs it when adjacent
+type hunterBot struct{}
+
+func (hunterBot) GetNextMove(state *bombahead.GameState, h *bombahead.GameHelpers) bombahead.Action {
+	if state.Me == nil {
+		return bombahead.DoNothing
+	}
+	me := state.Me.Pos
+	if !h.IsSafe(me) {
+		return bombahead.SafeFallback(state, h)
+	}
+
+	var target *bombahead.Player
+	for i := range state.Opponents {
+		o := &state.Opponents[i]
+		if o.Health > 0 && (target == nil || me.DistanceTo(o.Pos) < me.DistanceTo(target.Pos)) {
+			target = o
+		}
+	}
+	if target == nil {
+		return bombahead.DoNothing
+	}
+	if me.DistanceTo(target.Pos) <= 1 {
+		return bombahead.PlaceBomb
+	}
+	if next := h.GetNextActionTowards(me, target.Pos); next != bombahead.DoNothing {
+		return next
+	}
+	if box, ok := h.FindNearestBox(me); ok {
+		if me.DistanceTo(box) == 1 {
+			return bombahead.PlaceBomb
+		}
+		return h.GetNextActionTowards(me, box)
+	}
+	return bombahead.DoNothing
+}
@@ -0,0 +1,82 @@
+// Command bombahead-tournament ranks registered bots against each other
+//
+// It plays headless matches over many seeds and prints a leaderboard:
+//
+//	go run ./cmd/bombahead-tournament -format swiss -seeds 50
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/N3moAhead/bombahead-go/sim"
+	"github.com/N3moAhead/bombahead-go/tournament"
+)
+
+func main() {
+	rules := sim.DefaultRules()
+	cfg := tournament.Config{}
+	var format, names string
+	var list bool
+	flag.StringVar(&format, "format", string(tournament.RoundRobin), "pairing format: round-robin or swiss")
+	flag.StringVar(&names, "bots", "", "comma separated bots to enter (default: all registered)")
+	flag.BoolVar(&list, "list", false, "list registered bots and exit")
+	flag.IntVar(&cfg.Seeds, "seeds", 10, "boards per pairing, each played from both spawns")
+	flag.IntVar(&cfg.Rounds, "rounds", 0, "swiss rounds (default: log2(bots) + 1)")
+	flag.IntVar(&cfg.Parallel, "parallel", 0, "games played at once (default: number of CPUs)")
+	flag.Uint64Var(&cfg.BaseSeed, "seed", 1, "first board seed")
+	flag.IntVar(&rules.MaxTicks, "max-ticks", rules.MaxTicks, "ticks before a match ends undecided, 0 for the default")
+	flag.IntVar(&rules.Width, "width", rules.Width, "board width")
+	flag.IntVar(&rules.Height, "height", rules.Height, "board height")
+	flag.Float64Var(&rules.BoxDensity, "boxes", rules.BoxDensity, "chance that a free cell starts with a box")
+	flag.Float64Var(&cfg.KFactor, "k", 16, "Elo K-factor")
+	flag.Parse()
+
+	registered := tournament.Registered()
+	if list {
+		for _, e := range registered {
+			fmt.Println(e.Name)
+		}
+		return
+	}
+
+	entrants, err := selectBots(registered, names)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cfg.Format = tournament.Format(format)
+	cfg.Rules = rules
+
+	board, err := tournament.Run(entrants, cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := board.WriteTo(os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func selectBots(registered []tournament.Entrant, names string) ([]tournament.Entrant, error) {
+	if names == "" {
+		return registered, nil
+	}
+	var entrants []tournament.Entrant
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, e := range registered {
+			if e.Name == name {
+				entrants = append(entrants, e)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown bot %q (see -list)", name)
+		}
+	}
+	return entrants, nil
+}
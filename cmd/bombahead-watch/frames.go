@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bombahead "github.com/N3moAhead/bombahead-go"
+)
+
+// frame is one tick on screen
+type frame struct {
+	state *bombahead.GameState
+	// actions are the moves that led to this state, as reported to spectators
+	actions map[string]bombahead.Action
+	// chosen is the move our bot sent for this state, known for replays
+	chosen    bombahead.Action
+	hasChosen bool
+	// since is the time since the previous state and think the time our bot took to answer
+	since, think time.Duration
+	// note describes the end of the game after this state
+	note string
+}
+
+// framesFromRecording turns a replay into frames seen from the recorded bot
+func framesFromRecording(rec *bombahead.Recording) ([]frame, error) {
+	var frames []frame
+	var lastAt time.Time
+	for _, e := range rec.Entries {
+		switch {
+		case e.Direction == bombahead.ReplayIn && e.Type == "classic_state":
+			state, err := bombahead.ParseClassicState(e.Payload, rec.ClientID)
+			if err != nil {
+				return nil, fmt.Errorf("state %d: %w", len(frames), err)
+			}
+			f := frame{state: state}
+			if !lastAt.IsZero() {
+				f.since = e.Time.Sub(lastAt)
+			}
+			lastAt = e.Time
+			frames = append(frames, f)
+
+		case e.Direction == bombahead.ReplayOut && e.Type == "classic_input" && len(frames) > 0:
+			f := &frames[len(frames)-1]
+			if f.hasChosen {
+				continue
+			}
+			var input struct {
+				Move bombahead.Action `json:"move"`
+			}
+			if err := json.Unmarshal(e.Payload, &input); err == nil {
+				f.chosen, f.hasChosen = input.Move, true
+				f.think = e.Time.Sub(lastAt)
+			}
+
+		case e.Direction == bombahead.ReplayIn && e.Type == "back_to_lobby" && len(frames) > 0:
+			frames[len(frames)-1].note = gameEndNote(e.Payload)
+		}
+	}
+	if len(frames) == 0 {
+		return nil, fmt.Errorf("replay has no classic_state messages")
+	}
+	return frames, nil
+}
+
+// spectate reads a live spectator connection and sends a frame for every state
+func spectate(ctx context.Context, t bombahead.Transport, me string, out chan<- frame) error {
+	defer close(out)
+	var last *frame
+	lastAt := time.Now()
+	for {
+		msg, err := t.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		switch msg.Type {
+		case "classic_state":
+			state, err := bombahead.ParseClassicState(msg.Payload, me)
+			if err != nil {
+				continue
+			}
+			if me == "" {
+				// The parser guesses the first player, but a spectator has no perspective
+				state.Me = nil
+			}
+			var extra struct {
+				Actions map[string]bombahead.Action `json:"actions"`
+			}
+			_ = json.Unmarshal(msg.Payload, &extra)
+			now := time.Now()
+			last = &frame{state: state, actions: extra.Actions, since: now.Sub(lastAt)}
+			lastAt = now
+
+		case "back_to_lobby":
+			if last == nil {
+				continue
+			}
+			ended := *last
+			ended.note = gameEndNote(msg.Payload)
+			last = &ended
+
+		default:
+			continue
+		}
+
+		select {
+		case out <- *last:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func gameEndNote(payload json.RawMessage) string {
+	var end bombahead.GameEnd
+	_ = json.Unmarshal(payload, &end)
+	if end.Winner == "" {
+		return "game over, draw"
+	}
+	return fmt.Sprintf("game over, %s wins", end.Winner)
+}
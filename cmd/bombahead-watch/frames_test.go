@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	bombahead "github.com/N3moAhead/bombahead-go"
+)
+
+// statePayload is a classic_state on a 3x1 board with two players, plus the spectator actions if any
+func statePayload(tick int, actions map[string]bombahead.Action) json.RawMessage {
+	data, err := json.Marshal(map[string]any{
+		"tick":    tick,
+		"players": []bombahead.Player{{ID: "p1", Health: 3}, {ID: "p2", Pos: bombahead.Position{X: 2}, Health: 3}},
+		"field":   map[string]any{"width": 3, "height": 1, "field": []string{"AIR", "AIR", "AIR"}},
+		"actions": actions,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+func inputPayload(a bombahead.Action) json.RawMessage {
+	return json.RawMessage(fmt.Sprintf(`{"move":%q}`, a))
+}
+
+func TestFramesFromRecording(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+	entry := func(ms int, dir bombahead.ReplayDirection, msgType string, payload json.RawMessage) bombahead.ReplayEntry {
+		return bombahead.ReplayEntry{Time: at(ms), Direction: dir, Type: msgType, Payload: payload}
+	}
+
+	type want struct {
+		tick         int
+		chosen       bombahead.Action
+		hasChosen    bool
+		since, think time.Duration
+		note         string
+	}
+	tests := []struct {
+		name    string
+		entries []bombahead.ReplayEntry
+		want    []want
+		wantErr bool
+	}{
+		{
+			name: "game",
+			entries: []bombahead.ReplayEntry{
+				entry(0, bombahead.ReplayIn, "welcome", json.RawMessage(`{"clientId":"p1"}`)),
+				entry(0, bombahead.ReplayOut, "classic_input", inputPayload(bombahead.MoveUp)),
+				entry(10, bombahead.ReplayIn, "classic_state", statePayload(1, nil)),
+				entry(25, bombahead.ReplayOut, "classic_input", inputPayload(bombahead.MoveRight)),
+				entry(30, bombahead.ReplayOut, "classic_input", inputPayload(bombahead.PlaceBomb)),
+				entry(210, bombahead.ReplayIn, "classic_state", statePayload(2, nil)),
+				entry(220, bombahead.ReplayIn, "back_to_lobby", json.RawMessage(`{"winner":"p2"}`)),
+			},
+			want: []want{
+				{tick: 1, chosen: bombahead.MoveRight, hasChosen: true, think: 15 * time.Millisecond},
+				{tick: 2, since: 200 * time.Millisecond, note: "game over, p2 wins"},
+			},
+		},
+		{
+			name: "draw",
+			entries: []bombahead.ReplayEntry{
+				entry(0, bombahead.ReplayIn, "classic_state", statePayload(7, nil)),
+				entry(5, bombahead.ReplayIn, "back_to_lobby", nil),
+			},
+			want: []want{{tick: 7, note: "game over, draw"}},
+		},
+		{
+			name:    "no states",
+			entries: []bombahead.ReplayEntry{entry(0, bombahead.ReplayIn, "welcome", json.RawMessage(`{"clientId":"p1"}`))},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		frames, err := framesFromRecording(&bombahead.Recording{ClientID: "p1", Entries: tt.entries})
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%s: framesFromRecording() error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+
+		var got []want
+		for _, f := range frames {
+			if f.state.Me == nil || f.state.Me.ID != "p1" {
+				t.Fatalf("%s: frame Me = %+v, want p1", tt.name, f.state.Me)
+			}
+			got = append(got, want{tick: f.state.CurrentTick, chosen: f.chosen, hasChosen: f.hasChosen, since: f.since, think: f.think, note: f.note})
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Fatalf("%s: frames = %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSpectate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		me     string
+		wantMe string
+	}{
+		{name: "no perspective", me: ""},
+		{name: "as p2", me: "p2", wantMe: "p2"},
+	}
+	for _, tt := range tests {
+		client, server := bombahead.NewPipe()
+		moves := map[string]bombahead.Action{"p1": bombahead.MoveRight, "p2": bombahead.DoNothing}
+		for _, msg := range []struct {
+			msgType string
+			payload any
+		}{
+			{"game_start", nil},
+			{"classic_state", statePayload(0, nil)},
+			{"classic_state", json.RawMessage(`{"tick":"soon"}`)},
+			{"classic_state", statePayload(1, moves)},
+			{"back_to_lobby", map[string]string{"winner": "p1"}},
+		} {
+			if err := server.Send(msg.msgType, msg.payload); err != nil {
+				t.Fatalf("%s: Send(%s) error = %v", tt.name, msg.msgType, err)
+			}
+		}
+		server.Close()
+
+		out := make(chan frame, 8)
+		if err := spectate(context.Background(), client, tt.me, out); err == nil {
+			t.Fatalf("%s: spectate() error = nil, want the error of the closed transport", tt.name)
+		}
+
+		var ticks []int
+		var notes []string
+		var frames []frame
+		for f := range out {
+			frames = append(frames, f)
+			ticks = append(ticks, f.state.CurrentTick)
+			notes = append(notes, f.note)
+			if f.since < 0 {
+				t.Fatalf("%s: frame since = %v, want >= 0", tt.name, f.since)
+			}
+			if (f.state.Me == nil && tt.wantMe != "") || (f.state.Me != nil && f.state.Me.ID != tt.wantMe) {
+				t.Fatalf("%s: frame Me = %+v, want %q", tt.name, f.state.Me, tt.wantMe)
+			}
+		}
+		if want := []int{0, 1, 1}; !reflect.DeepEqual(ticks, want) {
+			t.Fatalf("%s: frame ticks = %v, want %v", tt.name, ticks, want)
+		}
+		if want := []string{"", "", "game over, p1 wins"}; !reflect.DeepEqual(notes, want) {
+			t.Fatalf("%s: frame notes = %q, want %q", tt.name, notes, want)
+		}
+		if frames[0].actions != nil || !reflect.DeepEqual(frames[1].actions, moves) {
+			t.Fatalf("%s: frame actions = %v, %v, want none, then %v", tt.name, frames[0].actions, frames[1].actions, moves)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Keys understood by the player; arrow keys are mapped to keyNext and keyPrev
+const (
+	keyPause   = ' '
+	keyNext    = 'n'
+	keyPrev    = 'p'
+	keyBack10  = '['
+	keyAhead10 = ']'
+	keyStart   = '0'
+	keyEnd     = '$'
+	keyFaster  = '+'
+	keySlower  = '-'
+	keyQuit    = 'q'
+)
+
+const (
+	helpText = "space pause  n/p or arrows step  [ ] jump 10  0 $ start/end  + - speed  q quit"
+	lineHelp = "no terminal: type a key and press enter, an empty line steps"
+)
+
+// readKeys switches the terminal to raw mode with stty and sends key presses
+// Without a terminal it falls back to reading one command per line
+// The returned function restores the terminal
+func readKeys() (<-chan byte, string, func()) {
+	keys := make(chan byte)
+	saved, err := stty("-g")
+	if err == nil {
+		_, err = stty("-icanon", "-echo", "min", "1")
+	}
+	if err != nil {
+		go readLines(keys)
+		return keys, lineHelp, func() {}
+	}
+
+	go readRaw(keys)
+	return keys, "", func() { _, _ = stty(strings.TrimSpace(saved)) }
+}
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+func readRaw(keys chan<- byte) {
+	r := bufio.NewReader(os.Stdin)
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			close(keys)
+			return
+		}
+		// Arrow keys arrive as ESC [ A-D
+		if b == 0x1b {
+			if next, _ := r.Peek(2); len(next) == 2 && next[0] == '[' {
+				_, _ = r.Discard(2)
+				switch next[1] {
+				case 'C', 'B':
+					b = keyNext
+				case 'D', 'A':
+					b = keyPrev
+				default:
+					continue
+				}
+			}
+		}
+		keys <- b
+	}
+}
+
+func readLines(keys chan<- byte) {
+	sc := bufio.NewScanner(os.Stdin)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if line == "" {
+			keys <- keyNext
+			continue
+		}
+		keys <- line[0]
+	}
+	close(keys)
+}
@@ -0,0 +1,191 @@
+// Command bombahead-watch shows games in the terminal
+//
+// Watch the matches of a local server live, or play back a replay written by bombahead.Recorder:
+//
+//	go run ./cmd/bombahead-watch
+//	go run ./cmd/bombahead-watch -replay game.jsonl.gz
+//
+// Replays can be paused, stepped and seeked; see the key help below the board
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	bombahead "github.com/N3moAhead/bombahead-go"
+)
+
+func main() {
+	var url, token, replay, me string
+	var interval time.Duration
+	var noColor bool
+	flag.StringVar(&url, "url", "ws://localhost:8038/spectate", "spectator endpoint of the server")
+	flag.StringVar(&token, "token", os.Getenv("BOMBAHEAD_TOKEN"), "auth token")
+	flag.StringVar(&replay, "replay", "", "play back this replay file instead of watching live")
+	flag.StringVar(&me, "me", "", "player ID to mark as me when watching live")
+	flag.DurationVar(&interval, "interval", 200*time.Millisecond, "time per tick when playing back a replay")
+	flag.BoolVar(&noColor, "no-color", false, "disable colors and screen clearing")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	keys, help, restore := readKeys()
+	defer restore()
+	if help == "" {
+		help = helpText
+	}
+
+	p := &player{view: view{color: !noColor}, help: help, interval: interval, speed: 1}
+	var err error
+	if replay != "" {
+		err = p.playReplay(ctx, replay, keys)
+	} else {
+		err = p.watchLive(ctx, url, token, me, keys)
+	}
+	restore()
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// player shows frames and handles the controls
+type player struct {
+	view     view
+	help     string
+	frames   []frame
+	idx      int
+	paused   bool
+	interval time.Duration
+	speed    float64
+	// live frames are appended while watching; unpaused, the newest one is shown
+	live bool
+}
+
+func (p *player) playReplay(ctx context.Context, path string, keys <-chan byte) error {
+	rec, err := bombahead.LoadReplay(path)
+	if err != nil {
+		return err
+	}
+	if p.frames, err = framesFromRecording(rec); err != nil {
+		return err
+	}
+	return p.loop(ctx, keys, nil)
+}
+
+func (p *player) watchLive(ctx context.Context, url, token, me string, keys <-chan byte) error {
+	t, err := bombahead.WebSocketDialer(ctx, url, token)
+	if err != nil {
+		return fmt.Errorf("connect to %s: %w", url, err)
+	}
+	stop := context.AfterFunc(ctx, func() { t.Close() })
+	defer stop()
+	defer t.Close()
+
+	frames := make(chan frame)
+	errs := make(chan error, 1)
+	go func() { errs <- spectate(ctx, t, me, frames) }()
+
+	p.live = true
+	fmt.Println("Waiting for a match...")
+	if err := p.loop(ctx, keys, frames); err != nil {
+		return err
+	}
+	t.Close()
+	return <-errs
+}
+
+func (p *player) loop(ctx context.Context, keys <-chan byte, frames <-chan frame) error {
+	timer := time.NewTimer(p.tickDelay())
+	defer timer.Stop()
+	p.show()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case f, ok := <-frames:
+			if !ok {
+				frames = nil
+				continue
+			}
+			p.frames = append(p.frames, f)
+			if !p.paused {
+				p.idx = len(p.frames) - 1
+			}
+			p.show()
+
+		case k, ok := <-keys:
+			if !ok {
+				keys = nil
+				continue
+			}
+			if k == keyQuit {
+				return nil
+			}
+			p.control(k)
+			p.show()
+
+		case <-timer.C:
+			timer.Reset(p.tickDelay())
+			if !p.live && !p.paused && p.idx < len(p.frames)-1 {
+				p.idx++
+				p.show()
+			}
+		}
+	}
+}
+
+func (p *player) control(k byte) {
+	last := len(p.frames) - 1
+	switch k {
+	case keyPause:
+		p.paused = !p.paused
+		if !p.paused && p.live {
+			p.idx = last
+		}
+	case keyNext:
+		p.paused = true
+		p.idx = min(p.idx+1, last)
+	case keyPrev:
+		p.paused = true
+		p.idx = max(p.idx-1, 0)
+	case keyAhead10:
+		p.idx = min(p.idx+10, last)
+	case keyBack10:
+		p.idx = max(p.idx-10, 0)
+	case keyStart:
+		p.idx = 0
+	case keyEnd:
+		p.idx = max(last, 0)
+	case keyFaster:
+		p.speed = min(p.speed*2, 16)
+	case keySlower:
+		p.speed = max(p.speed/2, 0.125)
+	}
+}
+
+func (p *player) tickDelay() time.Duration {
+	return time.Duration(float64(p.interval) / p.speed)
+}
+
+func (p *player) show() {
+	if len(p.frames) == 0 {
+		return
+	}
+	mode := fmt.Sprintf("replay %d/%d  %gx", p.idx+1, len(p.frames), p.speed)
+	if p.live {
+		mode = fmt.Sprintf("live %d/%d", p.idx+1, len(p.frames))
+	}
+	if p.paused {
+		mode += "  [paused]"
+	}
+	fmt.Print(p.view.draw(p.frames[p.idx], mode))
+	fmt.Printf("\n  %s\n", p.help)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiHome   = "\x1b[H\x1b[2J"
+	ansiBold   = "\x1b[1m"
+	ansiFaint  = "\x1b[90m"
+	ansiYellow = "\x1b[33m"
+	ansiBomb   = "\x1b[1;31m"
+	ansiBlast  = "\x1b[41;97m"
+)
+
+// playerColors are cycled through by player index
+var playerColors = []string{"\x1b[1;32m", "\x1b[1;36m", "\x1b[1;35m", "\x1b[1;34m"}
+
+// view draws frames as text
+type view struct {
+	color bool
+}
+
+// draw renders f and a status line; the board uses the symbols of GameState.Render
+func (v view) draw(f frame, status string) string {
+	var sb strings.Builder
+	s := f.state
+	if v.color {
+		sb.WriteString(ansiHome)
+	}
+
+	fmt.Fprintf(&sb, "tick %d", s.CurrentTick)
+	if s.MaxTicks > 0 {
+		fmt.Fprintf(&sb, "/%d", s.MaxTicks)
+	}
+	fmt.Fprintf(&sb, "   %s\n\n", status)
+
+	rows := strings.Split(s.Render(), "\n")[1 : 1+s.Field.Height]
+	for _, row := range rows {
+		sb.WriteString("  ")
+		for _, c := range []byte(row) {
+			sb.WriteString(v.cell(c))
+			sb.WriteByte(' ')
+		}
+		sb.WriteByte('\n')
+	}
+	sb.WriteByte('\n')
+
+	for i, p := range s.Players {
+		label := "?"
+		if i < 26 {
+			label = string(rune('A' + i))
+		}
+		if v.color {
+			label = playerColors[i%len(playerColors)] + label + ansiReset
+		}
+		fmt.Fprintf(&sb, "  %s %-12s hp %-2d score %-4d", label, p.ID, p.Health, p.Score)
+		if a, ok := f.actions[p.ID]; ok {
+			fmt.Fprintf(&sb, " last %-10s", a)
+		}
+		if s.Me != nil && s.Me.ID == p.ID {
+			sb.WriteString(" (me)")
+		}
+		if p.Health <= 0 {
+			sb.WriteString(" dead")
+		}
+		sb.WriteByte('\n')
+	}
+	sb.WriteByte('\n')
+
+	if f.hasChosen {
+		fmt.Fprintf(&sb, "  our bot chose %s in %s\n", f.chosen, f.think.Round(10*time.Microsecond))
+	}
+	if f.since > 0 {
+		fmt.Fprintf(&sb, "  %s since the previous tick\n", f.since.Round(10*time.Microsecond))
+	}
+	if f.note != "" {
+		fmt.Fprintf(&sb, "  %s\n", f.note)
+	}
+	return sb.String()
+}
+
+func (v view) cell(c byte) string {
+	if !v.color {
+		return string(c)
+	}
+	switch {
+	case c == '#':
+		return ansiFaint + "#" + ansiReset
+	case c == '+':
+		return ansiYellow + "+" + ansiReset
+	case c == '*':
+		return ansiBlast + "*" + ansiReset
+	case c >= '0' && c <= '9':
+		return ansiBomb + string(c) + ansiReset
+	case c >= 'A' && c <= 'Z':
+		return playerColors[int(c-'A')%len(playerColors)] + string(c) + ansiReset
+	case c >= 'a' && c <= 'z':
+		return ansiBold + playerColors[int(c-'a')%len(playerColors)] + string(c) + ansiReset
+	default:
+		return string(c)
+	}
+}
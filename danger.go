@@ -0,0 +1,175 @@
+package bombahead
+
+import "math/bits"
+
+// explosionDuration is the number of ticks a blast covers its cells
+const explosionDuration = 1
+
+// maxDangerTicks is how far ahead a DangerMap looks
+const maxDangerTicks = 64
+
+// DangerMap tells, for every cell, at which future ticks it is covered by an explosion
+// Ticks are counted from the current state: 0 is now, 1 the next state and so on
+// A bomb with fuse f explodes in f ticks, unless the blast of an earlier bomb reaches it first
+type DangerMap struct {
+	width, height int
+	// ticks has bit t set when the cell is covered t ticks from now
+	ticks []uint64
+	// bombTicks holds when each bomb goes off, blocking its cell until then
+	bombTicks map[Position]int
+}
+
+// DangerMap returns the explosion timeline of the current state
+// It is computed once per GameHelpers and follows chain reactions, including blasts
+// that reach further because an earlier explosion destroyed a box
+func (h *GameHelpers) DangerMap() *DangerMap {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.dangerMap == nil {
+		h.dangerMap = h.newDangerMap()
+	}
+	return h.dangerMap
+}
+
+func (h *GameHelpers) newDangerMap() *DangerMap {
+	state := h.State
+	m := &DangerMap{bombTicks: make(map[Position]int)}
+	if state == nil {
+		return m
+	}
+	m.width, m.height = state.Field.Width, state.Field.Height
+	m.ticks = make([]uint64, m.width*m.height)
+
+	burning := make(map[Position]bool, len(state.Explosions))
+	for _, e := range state.Explosions {
+		m.mark(e, 0)
+		burning[e] = true
+	}
+
+	bombIndex := make(map[Position]int, len(state.Bombs))
+	eta := make([]int, len(state.Bombs))
+	for i, b := range state.Bombs {
+		bombIndex[b.Pos] = i
+		eta[i] = max(b.Fuse, 1)
+		if burning[b.Pos] {
+			// A bomb caught in a current explosion goes off as soon as possible
+			eta[i] = 1
+		}
+	}
+
+	// Later blasts pass through boxes destroyed by earlier ones, so the field is updated as bombs go off
+	field := Field{Width: state.Field.Width, Height: state.Field.Height, Cells: append([]CellType(nil), state.Field.Cells...)}
+	exploded := make([]bool, len(state.Bombs))
+	for {
+		t := -1
+		for i := range state.Bombs {
+			if !exploded[i] && (t < 0 || eta[i] < t) {
+				t = eta[i]
+			}
+		}
+		if t < 0 {
+			break
+		}
+
+		// Every bomb going off at t, including those it sets off, explodes in the same step
+		var queue []int
+		for i := range state.Bombs {
+			if !exploded[i] && eta[i] == t {
+				exploded[i] = true
+				queue = append(queue, i)
+			}
+		}
+		var destroyed []Position
+		for len(queue) > 0 {
+			i := queue[0]
+			queue = queue[1:]
+
+			m.bombTicks[state.Bombs[i].Pos] = t
+			for _, cell := range BlastCells(field, state.Bombs[i].Pos, h.BombRange(state.Bombs[i])) {
+				m.mark(cell, t)
+				if field.CellAt(cell) == Box {
+					destroyed = append(destroyed, cell)
+				}
+				if j, ok := bombIndex[cell]; ok && !exploded[j] {
+					exploded[j] = true
+					eta[j] = t
+					queue = append(queue, j)
+				}
+			}
+		}
+		for _, cell := range destroyed {
+			field.Cells[cell.Y*field.Width+cell.X] = Air
+		}
+	}
+	return m
+}
+
+func (m *DangerMap) index(pos Position) (int, bool) {
+	if pos.X < 0 || pos.X >= m.width || pos.Y < 0 || pos.Y >= m.height {
+		return 0, false
+	}
+	return pos.Y*m.width + pos.X, true
+}
+
+func (m *DangerMap) mark(pos Position, tick int) {
+	idx, ok := m.index(pos)
+	if !ok {
+		return
+	}
+	for t := tick; t < tick+explosionDuration && t < maxDangerTicks; t++ {
+		m.ticks[idx] |= 1 << t
+	}
+}
+
+// ETA returns in how many ticks pos is first covered by an explosion
+// ok is false if no known explosion reaches pos
+func (m *DangerMap) ETA(pos Position) (ticks int, ok bool) {
+	idx, inBounds := m.index(pos)
+	if !inBounds || m.ticks[idx] == 0 {
+		return 0, false
+	}
+	return bits.TrailingZeros64(m.ticks[idx]), true
+}
+
+// Duration returns for how many consecutive ticks pos stays covered from its ETA on
+func (m *DangerMap) Duration(pos Position) int {
+	idx, ok := m.index(pos)
+	if !ok {
+		return 0
+	}
+	mask := m.ticks[idx]
+	return bits.TrailingZeros64(^(mask >> bits.TrailingZeros64(mask)))
+}
+
+// DangerousAt reports whether pos is covered by an explosion the given number of ticks from now
+func (m *DangerMap) DangerousAt(pos Position, ticks int) bool {
+	idx, ok := m.index(pos)
+	if !ok || ticks < 0 || ticks >= maxDangerTicks {
+		return false
+	}
+	return m.ticks[idx]&(1<<ticks) != 0
+}
+
+// clearFrom reports whether pos is never covered from the given tick on
+func (m *DangerMap) clearFrom(pos Position, tick int) bool {
+	idx, ok := m.index(pos)
+	if !ok {
+		return false
+	}
+	return tick >= maxDangerTicks || m.ticks[idx]>>tick == 0
+}
+
+// horizon returns the first tick from which on no cell is covered anymore
+func (m *DangerMap) horizon() int {
+	h := 0
+	for _, mask := range m.ticks {
+		h = max(h, bits.Len64(mask))
+	}
+	return h
+}
+
+// SafeFor reports whether pos is free of explosions for the given number of ticks, starting now
+func (m *DangerMap) SafeFor(pos Position, ticks int) bool {
+	eta, ok := m.ETA(pos)
+	return !ok || eta >= ticks
+}
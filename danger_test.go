@@ -0,0 +1,77 @@
+package bombahead
+
+import "testing"
+
+func TestDangerMap(t *testing.T) {
+	t.Parallel()
+
+	// The bomb at 0,0 goes off next tick and sets off the bomb at 2,0 early,
+	// whose blast reaches 4,0. The bomb at 0,3 destroys the box at 2,3 on tick 2,
+	// so the bomb at 3,3 reaches past it on tick 4.
+	state := MustParseASCII(`
+		1.5..
+		.....
+		.....
+		2.+4.
+		*...#
+	`)
+	m := NewGameHelpers(state).DangerMap()
+
+	tests := []struct {
+		name     string
+		pos      Position
+		eta      int
+		ok       bool
+		duration int
+	}{
+		{"bomb", Position{X: 0, Y: 0}, 1, true, 1},
+		{"chained bomb", Position{X: 2, Y: 0}, 1, true, 1},
+		{"chained blast", Position{X: 4, Y: 0}, 1, true, 1},
+		{"shared lane", Position{X: 0, Y: 1}, 1, true, 2},
+		{"destroyed box", Position{X: 2, Y: 3}, 2, true, 1},
+		{"late bomb", Position{X: 3, Y: 3}, 4, true, 1},
+		{"explosion now", Position{X: 0, Y: 4}, 0, true, 1},
+		{"untouched", Position{X: 4, Y: 1}, 0, false, 0},
+		{"out of bounds", Position{X: -1, Y: 0}, 0, false, 0},
+	}
+	for _, tt := range tests {
+		eta, ok := m.ETA(tt.pos)
+		if eta != tt.eta || ok != tt.ok {
+			t.Fatalf("%s: ETA(%v) = %d, %v, want %d, %v", tt.name, tt.pos, eta, ok, tt.eta, tt.ok)
+		}
+		if got := m.Duration(tt.pos); got != tt.duration {
+			t.Fatalf("%s: Duration(%v) = %d, want %d", tt.name, tt.pos, got, tt.duration)
+		}
+	}
+
+	// 1,3 is hit by the bomb at 0,3 and, once the box is gone, by the bomb at 3,3
+	if !m.DangerousAt(Position{X: 1, Y: 3}, 2) || m.DangerousAt(Position{X: 1, Y: 3}, 3) || !m.DangerousAt(Position{X: 1, Y: 3}, 4) {
+		t.Fatal("expected 1,3 to be dangerous on ticks 2 and 4 only")
+	}
+	if !m.DangerousAt(Position{X: 0, Y: 4}, 0) || !m.DangerousAt(Position{X: 0, Y: 4}, 2) {
+		t.Fatal("expected 0,4 to burn now and again on tick 2")
+	}
+	if !m.SafeFor(Position{X: 3, Y: 1}, 4) || m.SafeFor(Position{X: 3, Y: 1}, 5) {
+		t.Fatal("expected 3,1 to be safe for exactly 4 ticks")
+	}
+}
+
+func TestDangerMap_IsCachedAndCoversIsSafe(t *testing.T) {
+	t.Parallel()
+
+	state := MustParseASCII(`
+		.....
+		.#...
+		..1..
+		*....
+	`)
+	h := NewGameHelpers(state)
+	if h.DangerMap() != h.DangerMap() {
+		t.Fatal("expected DangerMap to be computed once")
+	}
+	for _, pos := range h.DangerPositions() {
+		if eta, ok := h.DangerMap().ETA(pos); !ok || eta > 1 {
+			t.Fatalf("ETA(%v) = %d, %v, want a hit within one tick like IsSafe", pos, eta, ok)
+		}
+	}
+}
@@ -21,3 +21,12 @@ const (
 	Wall CellType = "WALL"
 	Box  CellType = "BOX"
 )
+
+// PowerUpKind represents the kind of a power-up item
+type PowerUpKind string
+
+const (
+	PowerUpBombRange PowerUpKind = "BOMB_RANGE"
+	PowerUpExtraBomb PowerUpKind = "EXTRA_BOMB"
+	PowerUpSpeed     PowerUpKind = "SPEED"
+)
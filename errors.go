@@ -0,0 +1,60 @@
+package bombahead
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/N3moAhead/bombahead-go/internal/network"
+)
+
+// Errors returned by RunContext
+// The returned error wraps one of these values together with the underlying cause,
+// so callers can branch on it with errors.Is
+var (
+	// ErrDial means the connection to the game server could not be established
+	ErrDial = errors.New("dial failed")
+	// ErrAuthRejected means the server refused the authentication token
+	ErrAuthRejected = errors.New("authentication rejected")
+	// ErrProtocol means the server sent data that does not follow the protocol
+	ErrProtocol = errors.New("protocol error")
+	// ErrServerClosed means the server closed the connection
+	ErrServerClosed = errors.New("server closed connection")
+	// ErrConnectionLost means reading from or writing to the connection failed
+	ErrConnectionLost = errors.New("connection lost")
+)
+
+// classifyDialError maps a failure of the Dialer to one of the exported errors
+func classifyDialError(err error) error {
+	if errors.Is(err, ErrDial) || errors.Is(err, ErrAuthRejected) {
+		return err
+	}
+	if errors.Is(err, network.ErrUnauthorized) {
+		return fmt.Errorf("%w: %w", ErrAuthRejected, err)
+	}
+	return fmt.Errorf("%w: %w", ErrDial, err)
+}
+
+// classifyConnError maps a read or send failure to one of the exported errors
+// Cancellation of ctx takes precedence, since closing the connection is how
+// cancellation interrupts a blocking read
+func classifyConnError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+
+	switch {
+	case errors.Is(err, ErrDial), errors.Is(err, ErrAuthRejected), errors.Is(err, ErrProtocol),
+		errors.Is(err, ErrServerClosed), errors.Is(err, ErrConnectionLost):
+		return err
+	case errors.Is(err, network.ErrUnauthorized):
+		return fmt.Errorf("%w: %w", ErrAuthRejected, err)
+	case errors.Is(err, network.ErrInvalidEnvelope):
+		return fmt.Errorf("%w: %w", ErrProtocol, err)
+	case errors.Is(err, network.ErrClosed), errors.Is(err, io.EOF):
+		return fmt.Errorf("%w: %w", ErrServerClosed, err)
+	default:
+		return fmt.Errorf("%w: %w", ErrConnectionLost, err)
+	}
+}
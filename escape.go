@@ -0,0 +1,53 @@
+package bombahead
+
+// CanSafelyPlaceBomb reports whether a bomb placed at pos this tick leaves a way to safety
+// It is EscapePlanAfterBomb without the plan
+func (h *GameHelpers) CanSafelyPlaceBomb(pos Position) bool {
+	_, ok := h.EscapePlanAfterBomb(pos)
+	return ok
+}
+
+// EscapePlanAfterBomb plans how to get to safety after placing a bomb at pos this tick
+// The bomb is added hypothetically with a fresh fuse and the blasts are recomputed, including
+// chain reactions. The returned actions start next tick, as this tick is spent placing the bomb,
+// and end on a cell no known explosion reaches anymore. The bomb uses Me's BombRange when known.
+// ok is false if no such plan exists, pos already holds a bomb or Me has all its bombs out
+func (h *GameHelpers) EscapePlanAfterBomb(pos Position) (actions []Action, ok bool) {
+	if h.State == nil || !h.inBounds(pos) {
+		return nil, false
+	}
+	for _, b := range h.State.Bombs {
+		if b.Pos == pos {
+			return nil, false
+		}
+	}
+
+	bomb := Bomb{Pos: pos, Fuse: defaultBombFuse}
+	if me := h.State.Me; me != nil {
+		bomb.Owner = me.ID
+		bomb.Range = h.BombRange(bomb)
+		if me.MaxBombs > 0 && h.bombsOwnedBy(me.ID) >= me.MaxBombs {
+			return nil, false
+		}
+	}
+	withBomb := *h.State
+	withBomb.Bombs = append(append([]Bomb(nil), h.State.Bombs...), bomb)
+	hypo := NewGameHelpers(&withBomb)
+	danger := hypo.DangerMap()
+
+	// The player still stands on pos during the tick the bomb is placed
+	if danger.DangerousAt(pos, 1) {
+		return nil, false
+	}
+	return hypo.safePath(pos, 1, func(p Position, tick int) bool { return danger.clearFrom(p, tick) })
+}
+
+func (h *GameHelpers) bombsOwnedBy(id string) int {
+	n := 0
+	for _, b := range h.State.Bombs {
+		if b.Owner == id {
+			n++
+		}
+	}
+	return n
+}
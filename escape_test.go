@@ -0,0 +1,111 @@
+package bombahead
+
+import "testing"
+
+func TestEscapePlanAfterBomb(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		grid  string
+		steps int
+		ok    bool
+	}{
+		{
+			name: "corner with a way around",
+			grid: `
+				A..
+				...`,
+			steps: 2,
+			ok:    true,
+		},
+		{
+			name: "dead end corridor",
+			grid: `
+				A..#`,
+			ok: false,
+		},
+		{
+			name: "escape too far for the fuse",
+			grid: `
+				A..
+				.#.
+				...`,
+			ok: false,
+		},
+		{
+			name: "current bomb covers the cell while placing",
+			grid: `
+				A..
+				...
+				1..`,
+			ok: false,
+		},
+		{
+			name: "already on a bomb",
+			grid: `
+				a..
+				...
+				bomb 0,0 fuse=3`,
+			ok: false,
+		},
+		{
+			name: "short bomb range in a corridor",
+			grid: `
+				A....
+				A p1 range=1`,
+			steps: 2,
+			ok:    true,
+		},
+		{
+			name: "all bombs out",
+			grid: `
+				A..
+				...
+				A p1 bombs=1 me
+				bomb 2,1 fuse=5 owner=p1`,
+			ok: false,
+		},
+	}
+	for _, tt := range tests {
+		state := MustParseASCII(tt.grid)
+		h := NewGameHelpers(state)
+		me := state.Me.Pos
+
+		plan, ok := h.EscapePlanAfterBomb(me)
+		if ok != tt.ok || len(plan) != tt.steps {
+			t.Fatalf("%s: EscapePlanAfterBomb() = %v, %v, want %d steps, %v", tt.name, plan, ok, tt.steps, tt.ok)
+		}
+		if got := h.CanSafelyPlaceBomb(me); got != tt.ok {
+			t.Fatalf("%s: CanSafelyPlaceBomb() = %v, want %v", tt.name, got, tt.ok)
+		}
+		if !ok {
+			continue
+		}
+
+		// Replay the plan on the state with the bomb, starting a tick later
+		withBomb := MustParseASCII(state.Render() + "bomb 0,0 fuse=3 owner=" + state.Me.ID + "\n")
+		hypo := NewGameHelpers(withBomb)
+		end := walk(t, hypo, me, append([]Action{DoNothing}, plan...))
+		if _, hit := hypo.DangerMap().ETA(end); hit {
+			t.Fatalf("%s: plan %v ends on %v, which is still hit later", tt.name, plan, end)
+		}
+	}
+}
+
+func TestEscapePlanAfterBomb_KeepsState(t *testing.T) {
+	t.Parallel()
+
+	state := MustParseASCII(`
+		A..
+		...`)
+	h := NewGameHelpers(state)
+	h.EscapePlanAfterBomb(state.Me.Pos)
+
+	if len(state.Bombs) != 0 {
+		t.Fatalf("Bombs = %v, want the state left untouched", state.Bombs)
+	}
+	if _, hit := h.DangerMap().ETA(Position{X: 1, Y: 0}); hit {
+		t.Fatal("expected the hypothetical bomb to stay out of the helpers' DangerMap")
+	}
+}
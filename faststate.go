@@ -0,0 +1,447 @@
+package bombahead
+
+import "math/bits"
+
+// Bitset holds one bit per cell of a board in row-major order: cell i = y*width+x is bit i%64 of word i/64
+// Negative cells, such as FastState.Cell returns for positions outside the field, are never set
+type Bitset []uint64
+
+// Has reports whether cell i is set, it is false for negative cells
+func (b Bitset) Has(i int) bool {
+	return i >= 0 && b[i>>6]&(1<<(uint(i)&63)) != 0
+}
+
+// Set sets cell i, negative cells are ignored
+func (b Bitset) Set(i int) {
+	if i >= 0 {
+		b[i>>6] |= 1 << (uint(i) & 63)
+	}
+}
+
+// Clear clears cell i, negative cells are ignored
+func (b Bitset) Clear(i int) {
+	if i >= 0 {
+		b[i>>6] &^= 1 << (uint(i) & 63)
+	}
+}
+
+// Count returns the number of set cells
+func (b Bitset) Count() int {
+	n := 0
+	for _, w := range b {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// Empty reports whether no cell is set
+func (b Bitset) Empty() bool {
+	for _, w := range b {
+		if w != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Next returns the first set cell at or after i, or -1 if there is none
+// Loop over the set cells with: for i := b.Next(0); i >= 0; i = b.Next(i + 1)
+func (b Bitset) Next(i int) int {
+	i = max(i, 0)
+	word := i >> 6
+	if word >= len(b) {
+		return -1
+	}
+	w := b[word] &^ (1<<(uint(i)&63) - 1)
+	for w == 0 {
+		word++
+		if word >= len(b) {
+			return -1
+		}
+		w = b[word]
+	}
+	return word<<6 + bits.TrailingZeros64(w)
+}
+
+// FastPlayer is a Player of a FastState, with its position as a cell index
+type FastPlayer struct {
+	Cell      int
+	Health    int
+	Score     int
+	BombRange int
+	MaxBombs  int
+	Speed     int
+}
+
+// FastBomb is a Bomb of a FastState, with its position as a cell index
+// Owner is the index of the owner's ID in FastState.ID, or -1 if the bomb has none
+type FastBomb struct {
+	Cell  int
+	Fuse  int
+	Range int
+	Owner int
+}
+
+// FastItem is an Item of a FastState, with its position as a cell index
+type FastItem struct {
+	Cell int
+	Kind PowerUpKind
+}
+
+// fastLayout is the part of a FastState that never changes, shared by all its copies
+type fastLayout struct {
+	width, height, words int
+	// board has every cell of the field set, notFirstCol and notLastCol leave out the left and right edges
+	board, notFirstCol, notLastCol Bitset
+	// ids holds the player IDs followed by bomb owners that are not players
+	ids []string
+}
+
+// FastState is a compact copy of a GameState for search, cheap to copy and mutate
+// The board is kept as bitsets of cells, and players, bombs and items refer to cells by index.
+// Walls, Boxes, Bombs and Explosions share one allocation, and IDs are shared by all copies.
+// Cells that are neither walls nor boxes are air, and positions outside the field become cell -1.
+// Code that changes BombList must keep the cells in Bombs in step
+type FastState struct {
+	layout *fastLayout
+	// bits backs Walls, Boxes, Bombs and Explosions
+	bits []uint64
+	// scratch holds the bitsets blast propagation works in, see scratchSets; it is never copied
+	scratch []uint64
+
+	Walls      Bitset
+	Boxes      Bitset
+	Bombs      Bitset
+	Explosions Bitset
+
+	Tick     int
+	Round    int
+	MaxTicks int
+	// Me is the index of the bot's player in Players, or -1
+	Me       int
+	Players  []FastPlayer
+	BombList []FastBomb
+	Items    []FastItem
+}
+
+// NewFastState converts s into a FastState
+// Players come from s.Players, or from Me and Opponents when s has no Players
+func NewFastState(s *GameState) *FastState {
+	w, h := s.Field.Width, s.Field.Height
+	l := &fastLayout{width: w, height: h, words: (w*h + 63) / 64}
+	masks := make(Bitset, 3*l.words)
+	l.board, l.notFirstCol, l.notLastCol = masks[:l.words], masks[l.words:2*l.words], masks[2*l.words:]
+	for i := 0; i < w*h; i++ {
+		l.board.Set(i)
+		if i%w != 0 {
+			l.notFirstCol.Set(i)
+		}
+		if i%w != w-1 {
+			l.notLastCol.Set(i)
+		}
+	}
+
+	f := &FastState{layout: l, Tick: s.CurrentTick, Round: s.Round, MaxTicks: s.MaxTicks, Me: -1}
+	f.setBits(make([]uint64, 4*l.words))
+	for i := 0; i < w*h; i++ {
+		switch s.Field.CellAt(Position{X: i % w, Y: i / w}) {
+		case Wall:
+			f.Walls.Set(i)
+		case Box:
+			f.Boxes.Set(i)
+		}
+	}
+
+	players := s.renderedPlayers()
+	f.Players = make([]FastPlayer, len(players))
+	for i, p := range players {
+		l.ids = append(l.ids, p.ID)
+		f.Players[i] = FastPlayer{Cell: f.Cell(p.Pos), Health: p.Health, Score: p.Score, BombRange: p.BombRange, MaxBombs: p.MaxBombs, Speed: p.Speed}
+		if s.Me != nil && s.Me.ID == p.ID && f.Me < 0 {
+			f.Me = i
+		}
+	}
+
+	f.BombList = make([]FastBomb, len(s.Bombs))
+	for i, b := range s.Bombs {
+		f.BombList[i] = FastBomb{Cell: f.Cell(b.Pos), Fuse: b.Fuse, Range: b.Range, Owner: l.idIndex(b.Owner)}
+		f.setBit(f.Bombs, b.Pos)
+	}
+	for _, e := range s.Explosions {
+		f.setBit(f.Explosions, e)
+	}
+	f.Items = make([]FastItem, len(s.Items))
+	for i, it := range s.Items {
+		f.Items[i] = FastItem{Cell: f.Cell(it.Pos), Kind: it.Kind}
+	}
+	return f
+}
+
+func (l *fastLayout) idIndex(id string) int {
+	if id == "" {
+		return -1
+	}
+	for i, other := range l.ids {
+		if other == id {
+			return i
+		}
+	}
+	l.ids = append(l.ids, id)
+	return len(l.ids) - 1
+}
+
+func (f *FastState) setBits(b []uint64) {
+	n := f.layout.words
+	f.bits = b
+	f.Walls, f.Boxes, f.Bombs, f.Explosions = b[:n:n], b[n:2*n:2*n], b[2*n:3*n:3*n], b[3*n:]
+}
+
+// setBit sets pos in b, positions outside the field are dropped
+func (f *FastState) setBit(b Bitset, pos Position) {
+	b.Set(f.Cell(pos))
+}
+
+// Width returns the width of the field
+func (f *FastState) Width() int { return f.layout.width }
+
+// Height returns the height of the field
+func (f *FastState) Height() int { return f.layout.height }
+
+// InBounds reports whether pos lies on the field
+func (f *FastState) InBounds(pos Position) bool {
+	return pos.X >= 0 && pos.X < f.layout.width && pos.Y >= 0 && pos.Y < f.layout.height
+}
+
+// Cell returns the cell index of pos, or -1 if pos lies outside the field
+func (f *FastState) Cell(pos Position) int {
+	if !f.InBounds(pos) {
+		return -1
+	}
+	return pos.Y*f.layout.width + pos.X
+}
+
+// Pos returns the position of cell i, or {-1,-1} for the cell -1
+func (f *FastState) Pos(i int) Position {
+	if i < 0 {
+		return Position{X: -1, Y: -1}
+	}
+	return Position{X: i % f.layout.width, Y: i / f.layout.width}
+}
+
+// ID returns the ID with index i, as used by the players and bomb owners
+func (f *FastState) ID(i int) string {
+	if i < 0 || i >= len(f.layout.ids) {
+		return ""
+	}
+	return f.layout.ids[i]
+}
+
+// NewBitset returns an empty bitset sized for the field
+func (f *FastState) NewBitset() Bitset {
+	return make(Bitset, f.layout.words)
+}
+
+// Clone returns a copy of f that can be changed without affecting f
+func (f *FastState) Clone() *FastState {
+	c := &FastState{}
+	c.CopyFrom(f)
+	return c
+}
+
+// CopyFrom makes f a copy of src, reusing the memory of f where it is large enough
+// Search loops can keep one FastState per depth and copy into it without allocating
+func (f *FastState) CopyFrom(src *FastState) {
+	bits, players, bombs, items, scratch := f.bits, f.Players, f.BombList, f.Items, f.scratch
+	*f = *src
+	f.scratch = scratch
+	f.setBits(append(bits[:0], src.bits...))
+	f.Players = append(players[:0], src.Players...)
+	f.BombList = append(bombs[:0], src.BombList...)
+	f.Items = append(items[:0], src.Items...)
+}
+
+// GameState converts f back into a GameState
+// Explosions are listed in row-major order
+func (f *FastState) GameState() *GameState {
+	w, h := f.layout.width, f.layout.height
+	s := &GameState{
+		CurrentTick: f.Tick,
+		Round:       f.Round,
+		MaxTicks:    f.MaxTicks,
+		Field:       Field{Width: w, Height: h, Cells: make([]CellType, w*h)},
+	}
+	for i := range s.Field.Cells {
+		switch {
+		case f.Walls.Has(i):
+			s.Field.Cells[i] = Wall
+		case f.Boxes.Has(i):
+			s.Field.Cells[i] = Box
+		default:
+			s.Field.Cells[i] = Air
+		}
+	}
+
+	for i, p := range f.Players {
+		s.Players = append(s.Players, Player{ID: f.ID(i), Pos: f.Pos(p.Cell), Health: p.Health, Score: p.Score, BombRange: p.BombRange, MaxBombs: p.MaxBombs, Speed: p.Speed})
+	}
+	if f.Me >= 0 && f.Me < len(s.Players) {
+		me := s.Players[f.Me]
+		s.Me = &me
+		for i, p := range s.Players {
+			if i != f.Me {
+				s.Opponents = append(s.Opponents, p)
+			}
+		}
+	}
+
+	for _, b := range f.BombList {
+		s.Bombs = append(s.Bombs, Bomb{Pos: f.Pos(b.Cell), Fuse: b.Fuse, Owner: f.ID(b.Owner), Range: b.Range})
+	}
+	for i := f.Explosions.Next(0); i >= 0; i = f.Explosions.Next(i + 1) {
+		s.Explosions = append(s.Explosions, f.Pos(i))
+	}
+	for _, it := range f.Items {
+		s.Items = append(s.Items, Item{Pos: f.Pos(it.Cell), Kind: it.Kind})
+	}
+	return s
+}
+
+// BombRange returns the blast range of bomb i, resolved like GameHelpers.BombRange
+func (f *FastState) BombRange(i int) int {
+	b := f.BombList[i]
+	if b.Range > 0 {
+		return b.Range
+	}
+	if b.Owner >= 0 && b.Owner < len(f.Players) && f.Players[b.Owner].BombRange > 0 {
+		return f.Players[b.Owner].BombRange
+	}
+	return defaultBombRange
+}
+
+// Blast adds to dst the cells covered by bombs on every cell of origins with the given range
+// All origins spread at once with word-wide shifts, covering the same cells as BlastCells for each origin
+func (f *FastState) Blast(dst, origins Bitset, bombRange int) {
+	l := f.layout
+	scratch := f.scratchSets()
+	ray, next := scratch[0], scratch[1]
+
+	for i := range dst {
+		dst[i] |= origins[i] & l.board[i]
+	}
+	for dir := 0; dir < 4; dir++ {
+		copy(ray, origins)
+		for step := 1; step <= bombRange; step++ {
+			switch dir {
+			case 0:
+				shiftDown(next, ray, l.width)
+			case 1:
+				shiftUp(next, ray, 1)
+				andInto(next, l.notFirstCol)
+			case 2:
+				shiftUp(next, ray, l.width)
+			case 3:
+				shiftDown(next, ray, 1)
+				andInto(next, l.notLastCol)
+			}
+
+			alive := uint64(0)
+			for i := range next {
+				ray[i] = next[i] & l.board[i] &^ f.Walls[i]
+				dst[i] |= ray[i]
+				// A blast stops at the first box it reaches
+				ray[i] &^= f.Boxes[i]
+				alive |= ray[i]
+			}
+			if alive == 0 {
+				break
+			}
+		}
+	}
+}
+
+// ChainBlast sets off the bombs on the cells of start and every bomb their blasts reach
+// On return exploded holds the cells of all bombs that went off and covered the cells of their blasts.
+// Like the danger checks of GameHelpers, all blasts use the boxes of the current state
+func (f *FastState) ChainBlast(covered, exploded, start Bitset) {
+	scratch := f.scratchSets()
+	frontier, origins := scratch[2], scratch[3]
+	for i := range frontier {
+		frontier[i] = start[i] & f.Bombs[i]
+		exploded[i] |= frontier[i]
+	}
+
+	for !frontier.Empty() {
+		// Bombs of the same range spread together
+		for {
+			bombRange := -1
+			clear(origins)
+			for i, b := range f.BombList {
+				if !frontier.Has(b.Cell) {
+					continue
+				}
+				if r := f.BombRange(i); bombRange < 0 || r == bombRange {
+					bombRange = r
+					origins.Set(b.Cell)
+				}
+			}
+			if bombRange < 0 {
+				break
+			}
+			f.Blast(covered, origins, bombRange)
+			for i := range frontier {
+				frontier[i] &^= origins[i]
+			}
+		}
+
+		for i := range frontier {
+			frontier[i] = covered[i] & f.Bombs[i] &^ exploded[i]
+			exploded[i] |= frontier[i]
+		}
+	}
+}
+
+// scratchSets returns the four field-sized bitsets Blast and ChainBlast work in
+// Blast uses the first two and ChainBlast the others
+func (f *FastState) scratchSets() [4]Bitset {
+	n := f.layout.words
+	if len(f.scratch) < 4*n {
+		f.scratch = make([]uint64, 4*n)
+	}
+	return [4]Bitset{f.scratch[:n], f.scratch[n : 2*n], f.scratch[2*n : 3*n], f.scratch[3*n : 4*n]}
+}
+
+// shiftUp moves every bit of src n cells towards higher indexes into dst
+func shiftUp(dst, src Bitset, n int) {
+	words, off := n>>6, uint(n)&63
+	for i := len(dst) - 1; i >= 0; i-- {
+		var w uint64
+		if j := i - words; j >= 0 {
+			w = src[j] << off
+			if off != 0 && j > 0 {
+				w |= src[j-1] >> (64 - off)
+			}
+		}
+		dst[i] = w
+	}
+}
+
+// shiftDown moves every bit of src n cells towards lower indexes into dst
+func shiftDown(dst, src Bitset, n int) {
+	words, off := n>>6, uint(n)&63
+	for i := range dst {
+		var w uint64
+		if j := i + words; j < len(src) {
+			w = src[j] >> off
+			if off != 0 && j+1 < len(src) {
+				w |= src[j+1] << (64 - off)
+			}
+		}
+		dst[i] = w
+	}
+}
+
+func andInto(dst, mask Bitset) {
+	for i := range dst {
+		dst[i] &= mask[i]
+	}
+}
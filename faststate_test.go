@@ -0,0 +1,173 @@
+package bombahead
+
+import (
+	"math/rand/v2"
+	"reflect"
+	"testing"
+)
+
+func TestFastState_RoundTrip(t *testing.T) {
+	t.Parallel()
+
+	state := MustParseASCII(`
+		tick 9 round 2 maxTicks 300
+		#+..*
+		.A#2.
+		*..b.
+		A p1 hp=2 score=4 range=3 bombs=2
+		B p2 hp=3 score=1 speed=1 me
+		bomb 3,1 fuse=2 owner=p1
+		bomb 3,2 fuse=1 owner=p9 range=4
+		item 4,1 EXTRA_BOMB
+	`)
+	got := NewFastState(state).GameState()
+	if !reflect.DeepEqual(got, state) {
+		t.Fatalf("GameState() =\n%s\nwant\n%s", got, state)
+	}
+}
+
+func TestFastState_CloneIsIndependent(t *testing.T) {
+	t.Parallel()
+
+	state := MustParseASCII(`
+		A+.
+		.2.
+	`)
+	f := NewFastState(state)
+	want := f.GameState()
+
+	c := f.Clone()
+	c.Boxes.Clear(c.Cell(Position{X: 1, Y: 0}))
+	c.Explosions.Set(c.Cell(Position{X: 2, Y: 1}))
+	c.Players[0].Health = 0
+	c.BombList[0].Fuse = 9
+	if got := f.GameState(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("original changed through clone:\n%s\nwant\n%s", got, want)
+	}
+
+	var reused FastState
+	reused.CopyFrom(c)
+	reused.CopyFrom(f)
+	if got := reused.GameState(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("CopyFrom() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// TestFastState_CopyFromReusesMemory is not parallel, as AllocsPerRun does not allow it
+func TestFastState_CopyFromReusesMemory(t *testing.T) {
+	f := NewFastState(benchmarkState())
+	var c FastState
+	c.CopyFrom(f)
+	if allocs := testing.AllocsPerRun(100, func() { c.CopyFrom(f) }); allocs != 0 {
+		t.Fatalf("CopyFrom() allocs = %v, want 0", allocs)
+	}
+}
+
+func TestFastState_BlastMatchesBlastCells(t *testing.T) {
+	t.Parallel()
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	// 70 columns make rows span more than one word
+	for _, size := range [][2]int{{5, 5}, {11, 11}, {70, 3}, {3, 40}} {
+		field := Field{Width: size[0], Height: size[1], Cells: make([]CellType, size[0]*size[1])}
+		for i := range field.Cells {
+			field.Cells[i] = []CellType{Air, Air, Wall, Box}[rng.IntN(4)]
+		}
+		f := NewFastState(&GameState{Field: field})
+
+		for cell := 0; cell < size[0]*size[1]; cell++ {
+			for bombRange := 1; bombRange <= 4; bombRange++ {
+				origin := f.Pos(cell)
+				want := f.NewBitset()
+				for _, pos := range BlastCells(field, origin, bombRange) {
+					want.Set(f.Cell(pos))
+				}
+				origins, got := f.NewBitset(), f.NewBitset()
+				origins.Set(cell)
+				f.Blast(got, origins, bombRange)
+				if !reflect.DeepEqual(got, want) {
+					t.Fatalf("%dx%d: Blast(%v, range %d) covers %d cells, BlastCells %d", size[0], size[1], origin, bombRange, got.Count(), want.Count())
+				}
+			}
+		}
+	}
+}
+
+func TestFastState_ChainBlastMatchesDangerPositions(t *testing.T) {
+	t.Parallel()
+
+	state := MustParseASCII(`
+		A......
+		.#.#+#.
+		..1...3
+		.#.#.#.
+		*.4..2.
+		A p1 range=1
+		B p2 at=6,0 range=4
+		bomb 2,2 fuse=1 owner=p2
+		bomb 6,2 fuse=3 owner=p1
+		bomb 2,4 fuse=4 range=2
+		bomb 5,4 fuse=2 owner=p1
+	`)
+	f := NewFastState(state)
+	start, covered, exploded := f.NewBitset(), f.NewBitset(), f.NewBitset()
+	for _, b := range f.BombList {
+		if b.Fuse <= 1 {
+			start.Set(b.Cell)
+		}
+	}
+	for i := range start {
+		start[i] |= f.Explosions[i]
+		covered[i] = f.Explosions[i]
+	}
+	f.ChainBlast(covered, exploded, start)
+
+	var got []Position
+	for i := covered.Next(0); i >= 0; i = covered.Next(i + 1) {
+		got = append(got, f.Pos(i))
+	}
+	if want := NewGameHelpers(state).DangerPositions(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("ChainBlast() covers %v, want %v", got, want)
+	}
+	if exploded.Count() != 3 || exploded.Has(f.Cell(Position{X: 5, Y: 4})) {
+		t.Fatalf("ChainBlast() exploded %d bombs, want the three in the chain", exploded.Count())
+	}
+}
+
+func BenchmarkFastState_CopyFrom(b *testing.B) {
+	f := NewFastState(benchmarkState())
+	var c FastState
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		c.CopyFrom(f)
+	}
+}
+
+func BenchmarkFastState_ChainBlast(b *testing.B) {
+	f := NewFastState(benchmarkState())
+	start, covered, exploded := f.NewBitset(), f.NewBitset(), f.NewBitset()
+	copy(start, f.Bombs)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		clear(covered)
+		clear(exploded)
+		f.ChainBlast(covered, exploded, start)
+	}
+}
+
+func TestBitset_IgnoresNegativeCells(t *testing.T) {
+	t.Parallel()
+
+	f := NewFastState(MustParseASCII("..."))
+	b := f.NewBitset()
+	outside := f.Cell(Position{X: 5, Y: 0})
+	b.Set(outside)
+	b.Clear(outside)
+	if b.Has(outside) || !b.Empty() {
+		t.Fatalf("Bitset = %v after setting cell %d, want it empty", b, outside)
+	}
+	b.Set(2)
+	if got := b.Next(-1); got != 2 {
+		t.Fatalf("Next(-1) = %d, want 2", got)
+	}
+}
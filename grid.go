@@ -0,0 +1,85 @@
+package bombahead
+
+import "sync"
+
+// Grid holds one value per cell of a Width x Height board, stored row-major in Cells
+type Grid[T any] struct {
+	Width  int
+	Height int
+	Cells  []T
+}
+
+// NewGrid returns a grid of the given size with every cell set to the zero value
+func NewGrid[T any](width, height int) Grid[T] {
+	return Grid[T]{Width: width, Height: height, Cells: make([]T, width*height)}
+}
+
+// InBounds reports whether pos lies on the grid
+func (g Grid[T]) InBounds(pos Position) bool {
+	return pos.X >= 0 && pos.X < g.Width && pos.Y >= 0 && pos.Y < g.Height
+}
+
+// Index returns the index of pos in Cells, ok is false if pos is out of bounds
+func (g Grid[T]) Index(pos Position) (idx int, ok bool) {
+	if !g.InBounds(pos) {
+		return 0, false
+	}
+	return pos.Y*g.Width + pos.X, true
+}
+
+// Pos returns the position of the cell at index idx
+func (g Grid[T]) Pos(idx int) Position {
+	return Position{X: idx % g.Width, Y: idx / g.Width}
+}
+
+// At returns the value at pos, or the zero value if pos is out of bounds
+func (g Grid[T]) At(pos Position) T {
+	idx, ok := g.Index(pos)
+	if !ok {
+		var zero T
+		return zero
+	}
+	return g.Cells[idx]
+}
+
+// Set stores v at pos, positions out of bounds are ignored
+func (g Grid[T]) Set(pos Position, v T) {
+	if idx, ok := g.Index(pos); ok {
+		g.Cells[idx] = v
+	}
+}
+
+// Fill sets every cell to v
+func (g Grid[T]) Fill(v T) {
+	for i := range g.Cells {
+		g.Cells[i] = v
+	}
+}
+
+// gridPool recycles the backing slices of grids the helpers only need during a call
+type gridPool[T any] struct {
+	pool sync.Pool
+}
+
+// The helper searches only need these grid types
+var (
+	boolGrids gridPool[bool]
+	intGrids  gridPool[int]
+)
+
+// get returns a grid of the given size with every cell set to the zero value
+func (p *gridPool[T]) get(width, height int) Grid[T] {
+	n := width * height
+	if cells, ok := p.pool.Get().(*[]T); ok && cap(*cells) >= n {
+		g := Grid[T]{Width: width, Height: height, Cells: (*cells)[:n]}
+		clear(g.Cells)
+		return g
+	}
+	return NewGrid[T](width, height)
+}
+
+// put hands the cells of g back for reuse, g must not be used afterwards
+func (p *gridPool[T]) put(g Grid[T]) {
+	cells := g.Cells
+	p.pool.Put(&cells)
+}
@@ -0,0 +1,54 @@
+package bombahead
+
+import "testing"
+
+func TestGrid(t *testing.T) {
+	t.Parallel()
+
+	g := NewGrid[int](3, 2)
+	g.Fill(7)
+	g.Set(Position{X: 2, Y: 1}, 1)
+	g.Set(Position{X: 3, Y: 0}, 9)
+
+	if got := g.At(Position{X: 2, Y: 1}); got != 1 {
+		t.Fatalf("At({2,1}) = %d, want 1", got)
+	}
+	if got := g.At(Position{X: 0, Y: 0}); got != 7 {
+		t.Fatalf("At({0,0}) = %d, want 7", got)
+	}
+	if got := g.At(Position{X: -1, Y: 0}); got != 0 {
+		t.Fatalf("At(out of bounds) = %d, want 0", got)
+	}
+	if idx, ok := g.Index(Position{X: 1, Y: 1}); !ok || idx != 4 {
+		t.Fatalf("Index({1,1}) = %d, %v, want 4, true", idx, ok)
+	}
+	if _, ok := g.Index(Position{X: 3, Y: 0}); ok {
+		t.Fatal("expected Index to reject a position past the row end")
+	}
+	if got := g.Pos(5); got != (Position{X: 2, Y: 1}) {
+		t.Fatalf("Pos(5) = %+v, want {2,1}", got)
+	}
+}
+
+func TestGridPool_ReturnsClearedGrids(t *testing.T) {
+	t.Parallel()
+
+	var pool gridPool[int]
+	g := pool.get(4, 3)
+	g.Fill(5)
+	pool.put(g)
+
+	for _, size := range [][2]int{{4, 3}, {2, 2}, {6, 5}} {
+		g := pool.get(size[0], size[1])
+		if g.Width != size[0] || g.Height != size[1] || len(g.Cells) != size[0]*size[1] {
+			t.Fatalf("get(%d, %d) = %dx%d with %d cells", size[0], size[1], g.Width, g.Height, len(g.Cells))
+		}
+		for i, v := range g.Cells {
+			if v != 0 {
+				t.Fatalf("get(%d, %d).Cells[%d] = %d, want 0", size[0], size[1], i, v)
+			}
+		}
+		g.Fill(5)
+		pool.put(g)
+	}
+}
@@ -1,12 +1,30 @@
 package bombahead
 
+import (
+	"cmp"
+	"slices"
+	"sync"
+)
+
 // GameHelpers provides utility functions for analyzing the game state
+// It is safe for concurrent use, as long as State is not changed meanwhile
 type GameHelpers struct {
 	State *GameState
+
+	// mu guards the caches below, which are filled lazily
+	mu sync.Mutex
+	// dangerMap is computed on first use, as State does not change during a tick
+	dangerMap *DangerMap
+	// paths caches the searches of DistanceMap, ShortestPath and NextActionsTowardsAny by start
+	paths map[Position]*pathTree
 }
 
+// defaultBombRange is the blast range used when neither the bomb nor its owner carries one
 const defaultBombRange = 2
 
+// defaultBombFuse is the fuse of a freshly placed bomb, as the protocol does not send it
+const defaultBombFuse = 3
+
 // NewGameHelpers creates a new instance of GameHelpers
 func NewGameHelpers(state *GameState) *GameHelpers {
 	return &GameHelpers{State: state}
@@ -18,8 +36,7 @@ func (h *GameHelpers) IsWalkable(pos Position) bool {
 		return false
 	}
 
-	cell := h.State.Field.CellAt(pos)
-	if cell == Wall || cell == Box {
+	if !walkableCell(h.State.Field.CellAt(pos)) {
 		return false
 	}
 
@@ -32,23 +49,30 @@ func (h *GameHelpers) IsWalkable(pos Position) bool {
 	return true
 }
 
+// walkableCell reports whether a cell of this type can be entered, leaving bombs aside
+// Only walls and boxes block, so unknown cell types count as walkable
+func walkableCell(cell CellType) bool {
+	return cell != Wall && cell != Box
+}
+
 // GetAdjacentWalkablePositions returns a list of valid adjacent positions
 func (h *GameHelpers) GetAdjacentWalkablePositions(pos Position) []Position {
-	candidates := []Position{
+	return h.appendAdjacentWalkable(make([]Position, 0, 4), pos)
+}
+
+// appendAdjacentWalkable appends the walkable neighbours of pos to dst, so searches can reuse one buffer
+func (h *GameHelpers) appendAdjacentWalkable(dst []Position, pos Position) []Position {
+	for _, next := range [4]Position{
 		{X: pos.X, Y: pos.Y - 1},
 		{X: pos.X + 1, Y: pos.Y},
 		{X: pos.X, Y: pos.Y + 1},
 		{X: pos.X - 1, Y: pos.Y},
-	}
-
-	result := make([]Position, 0, 4)
-	for _, next := range candidates {
+	} {
 		if h.IsWalkable(next) {
-			result = append(result, next)
+			dst = append(dst, next)
 		}
 	}
-
-	return result
+	return dst
 }
 
 // GetNextActionTowards returns the next action from start towards target using BFS
@@ -57,17 +81,7 @@ func (h *GameHelpers) GetNextActionTowards(start, target Position) Action {
 		return DoNothing
 	}
 
-	prev := h.bfs(start, func(pos Position) bool { return pos == target }, false)
-	if prev == nil {
-		return DoNothing
-	}
-
-	path := rebuildPath(start, target, prev)
-	if len(path) < 2 {
-		return DoNothing
-	}
-
-	return actionFromStep(path[0], path[1])
+	return h.firstStepTowards(start, target, false)
 }
 
 // IsSafe checks if a position is currently safe from known explosions and bomb blast lanes
@@ -82,58 +96,45 @@ func (h *GameHelpers) IsSafe(pos Position) bool {
 		}
 	}
 
-	danger := h.computeDangerPositions()
-	if danger[pos] {
-		return false
-	}
-
-	return true
+	danger, _ := h.computeDangerPositions()
+	defer boolGrids.put(danger)
+	return !danger.At(pos)
 }
 
 // GetNearestSafePosition finds the closest safe position from start using BFS
 func (h *GameHelpers) GetNearestSafePosition(start Position) Position {
-	if h.IsWalkable(start) && h.IsSafe(start) {
-		return start
+	danger, _ := h.computeDangerPositions()
+	defer boolGrids.put(danger)
+	safe := func(pos Position) bool {
+		return h.IsWalkable(pos) && !danger.At(pos)
 	}
 
-	prev := h.bfs(start, func(pos Position) bool {
-		return h.IsWalkable(pos) && h.IsSafe(pos)
-	}, true)
-	if prev == nil {
+	if safe(start) {
 		return start
 	}
 
-	queue := []Position{start}
-	visited := map[Position]bool{start: true}
-	for len(queue) > 0 {
-		cur := queue[0]
-		queue = queue[1:]
-
-		if h.IsWalkable(cur) && h.IsSafe(cur) {
-			return cur
-		}
-
-		for _, next := range h.GetAdjacentWalkablePositions(cur) {
-			if !visited[next] {
-				visited[next] = true
-				queue = append(queue, next)
-			}
-		}
+	prev, found, ok := h.bfs(start, safe, true)
+	intGrids.put(prev)
+	if !ok {
+		return start
 	}
-
-	return start
+	return found
 }
 
 // FindNearestBox locates the closest box position from start
 func (h *GameHelpers) FindNearestBox(start Position) (Position, bool) {
-	queue := []Position{start}
-	visited := map[Position]bool{start: true}
+	field := h.State.Field
+	visited := boolGrids.get(field.Width, field.Height)
+	defer boolGrids.put(visited)
 
-	for len(queue) > 0 {
-		cur := queue[0]
-		queue = queue[1:]
+	queue := make([]Position, 1, visited.Width+visited.Height+1)
+	queue[0] = start
+	visited.Set(start, true)
 
-		if h.State.Field.CellAt(cur) == Box {
+	for head := 0; head < len(queue); head++ {
+		cur := queue[head]
+
+		if field.CellAt(cur) == Box {
 			return cur, true
 		}
 
@@ -143,19 +144,15 @@ func (h *GameHelpers) FindNearestBox(start Position) (Position, bool) {
 			{X: cur.X, Y: cur.Y + 1},
 			{X: cur.X - 1, Y: cur.Y},
 		} {
-			if next.X < 0 || next.X >= h.State.Field.Width || next.Y < 0 || next.Y >= h.State.Field.Height {
-				continue
-			}
-			if visited[next] {
+			idx, ok := visited.Index(next)
+			if !ok || visited.Cells[idx] {
 				continue
 			}
-
-			cell := h.State.Field.CellAt(next)
-			if cell == Wall {
+			if field.CellAt(next) == Wall {
 				continue
 			}
 
-			visited[next] = true
+			visited.Cells[idx] = true
 			queue = append(queue, next)
 		}
 	}
@@ -163,53 +160,65 @@ func (h *GameHelpers) FindNearestBox(start Position) (Position, bool) {
 	return Position{}, false
 }
 
-func (h *GameHelpers) bfs(start Position, goal func(Position) bool, allowUnsafeStart bool) map[Position]Position {
+// Values of the prev grid of bfs besides the index of the previous cell
+const (
+	bfsUnvisited = -1
+	bfsFromStart = -2
+)
+
+// bfs searches walkable cells from start until goal accepts one other than start
+// prev holds for every reached cell the index it was reached from, or bfsFromStart for the neighbours of start.
+// It comes from intGrids and must be put back by the caller
+func (h *GameHelpers) bfs(start Position, goal func(Position) bool, allowUnsafeStart bool) (prev Grid[int], found Position, ok bool) {
+	prev = intGrids.get(h.State.Field.Width, h.State.Field.Height)
 	if !allowUnsafeStart && !h.IsWalkable(start) {
-		return nil
+		return prev, Position{}, false
 	}
 
-	queue := []Position{start}
-	visited := map[Position]bool{start: true}
-	prev := make(map[Position]Position)
+	prev.Fill(bfsUnvisited)
+	// start may lie outside the field, so it is never looked up in prev
+	prev.Set(start, bfsFromStart)
+	queue := make([]Position, 1, prev.Width+prev.Height+1)
+	queue[0] = start
+	var neighbours [4]Position
 
-	for len(queue) > 0 {
-		cur := queue[0]
-		queue = queue[1:]
+	for head := 0; head < len(queue); head++ {
+		cur := queue[head]
 
 		if cur != start && goal(cur) {
-			return prev
+			return prev, cur, true
 		}
 
-		for _, next := range h.GetAdjacentWalkablePositions(cur) {
-			if visited[next] {
+		from := bfsFromStart
+		if cur != start {
+			from, _ = prev.Index(cur)
+		}
+		for _, next := range h.appendAdjacentWalkable(neighbours[:0], cur) {
+			idx, _ := prev.Index(next)
+			if prev.Cells[idx] != bfsUnvisited {
 				continue
 			}
-			visited[next] = true
-			prev[next] = cur
+			prev.Cells[idx] = from
 			queue = append(queue, next)
 		}
 	}
 
-	return nil
+	return prev, Position{}, false
 }
 
-func rebuildPath(start, target Position, prev map[Position]Position) []Position {
-	path := []Position{target}
-	cur := target
-	for cur != start {
-		p, ok := prev[cur]
-		if !ok {
-			return nil
-		}
-		cur = p
-		path = append(path, cur)
+// firstStepTowards returns the first move of a shortest path from start to target, or DoNothing if there is none
+func (h *GameHelpers) firstStepTowards(start, target Position, allowUnsafeStart bool) Action {
+	prev, _, ok := h.bfs(start, func(pos Position) bool { return pos == target }, allowUnsafeStart)
+	defer intGrids.put(prev)
+	if !ok {
+		return DoNothing
 	}
 
-	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
-		path[i], path[j] = path[j], path[i]
+	idx, _ := prev.Index(target)
+	for prev.Cells[idx] != bfsFromStart {
+		idx = prev.Cells[idx]
 	}
-
-	return path
+	return actionFromStep(start, prev.Pos(idx))
 }
 
 func actionFromStep(from, to Position) Action {
@@ -227,28 +236,76 @@ func actionFromStep(from, to Position) Action {
 	}
 }
 
-func (h *GameHelpers) computeDangerPositions() map[Position]bool {
-	danger := make(map[Position]bool)
-	if h.State == nil {
-		return danger
+// DangerPositions returns the cells IsSafe treats as dangerous because of explosions and bombs, in row-major order
+// These are the active explosions and the blasts of bombs that trigger now (Fuse <= 1) or by chain reaction.
+// Explosions and bombs outside the field, which lenient parsing lets through, are included too
+func (h *GameHelpers) DangerPositions() []Position {
+	danger, outside := h.computeDangerPositions()
+	defer boolGrids.put(danger)
+	positions := make([]Position, 0, len(outside))
+	for i, hit := range danger.Cells {
+		if hit {
+			positions = append(positions, danger.Pos(i))
+		}
 	}
+	if len(outside) > 0 {
+		positions = append(positions, outside...)
+		slices.SortFunc(positions, func(a, b Position) int {
+			return cmp.Or(cmp.Compare(a.Y, b.Y), cmp.Compare(a.X, b.X))
+		})
+	}
+	return positions
+}
 
-	bombIndex := make(map[Position]int, len(h.State.Bombs))
+// computeDangerPositions marks the cells of DangerPositions in a grid from boolGrids, which the caller must put back
+// Dangerous positions outside the field are returned in outside, without duplicates
+func (h *GameHelpers) computeDangerPositions() (danger Grid[bool], outside []Position) {
+	if h.State == nil {
+		return boolGrids.get(0, 0), nil
+	}
+	width, height := h.State.Field.Width, h.State.Field.Height
+	danger = boolGrids.get(width, height)
+
+	// bombIndex holds the index of the bomb on each cell plus one, zero for cells without a bomb.
+	// Bombs outside the field go to outsideBombs, so they still chain like the others
+	bombIndex := intGrids.get(width, height)
+	defer intGrids.put(bombIndex)
+	var outsideBombs map[Position]int
 	for i, b := range h.State.Bombs {
-		bombIndex[b.Pos] = i
+		if bombIndex.InBounds(b.Pos) {
+			bombIndex.Set(b.Pos, i+1)
+			continue
+		}
+		if outsideBombs == nil {
+			outsideBombs = make(map[Position]int)
+		}
+		outsideBombs[b.Pos] = i + 1
 	}
 
 	triggered := make([]bool, len(h.State.Bombs))
 	queue := make([]int, 0, len(h.State.Bombs))
-
-	for _, e := range h.State.Explosions {
-		danger[e] = true
-		if idx, ok := bombIndex[e]; ok && !triggered[idx] {
+	mark := func(pos Position) {
+		idx, ok := danger.Index(pos)
+		switch {
+		case ok:
+			danger.Cells[idx] = true
+			idx = bombIndex.Cells[idx] - 1
+		case !slices.Contains(outside, pos):
+			outside = append(outside, pos)
+			idx = outsideBombs[pos] - 1
+		default:
+			return
+		}
+		if idx >= 0 && !triggered[idx] {
 			triggered[idx] = true
 			queue = append(queue, idx)
 		}
 	}
 
+	for _, e := range h.State.Explosions {
+		mark(e)
+	}
+
 	for i, b := range h.State.Bombs {
 		if b.Fuse <= 1 && !triggered[i] {
 			triggered[i] = true
@@ -260,20 +317,54 @@ func (h *GameHelpers) computeDangerPositions() map[Position]bool {
 		idx := queue[0]
 		queue = queue[1:]
 
-		blast := h.blastCells(h.State.Bombs[idx].Pos)
-		for _, cell := range blast {
-			danger[cell] = true
-			if hitIdx, ok := bombIndex[cell]; ok && !triggered[hitIdx] {
-				triggered[hitIdx] = true
-				queue = append(queue, hitIdx)
-			}
+		for _, cell := range h.blastCells(h.State.Bombs[idx]) {
+			mark(cell)
 		}
 	}
 
-	return danger
+	return danger, outside
+}
+
+func (h *GameHelpers) blastCells(b Bomb) []Position {
+	return BlastCells(h.State.Field, b.Pos, h.BombRange(b))
+}
+
+// BombRange returns the blast range of b
+// It is the bomb's own Range, else the BombRange of its owner, else the default of 2
+func (h *GameHelpers) BombRange(b Bomb) int {
+	if b.Range > 0 {
+		return b.Range
+	}
+	if owner, ok := h.player(b.Owner); ok && owner.BombRange > 0 {
+		return owner.BombRange
+	}
+	return defaultBombRange
+}
+
+// player looks up a player by ID in the state
+func (h *GameHelpers) player(id string) (Player, bool) {
+	if id == "" || h.State == nil {
+		return Player{}, false
+	}
+	for _, p := range h.State.Players {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	if h.State.Me != nil && h.State.Me.ID == id {
+		return *h.State.Me, true
+	}
+	for _, p := range h.State.Opponents {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return Player{}, false
 }
 
-func (h *GameHelpers) blastCells(origin Position) []Position {
+// BlastCells returns the cells covered by a bomb at origin with the given range
+// The blast spreads in the four directions, stops at walls and includes the first box it hits
+func BlastCells(field Field, origin Position, bombRange int) []Position {
 	cells := []Position{origin}
 	directions := []Position{
 		{X: 0, Y: -1},
@@ -283,16 +374,16 @@ func (h *GameHelpers) blastCells(origin Position) []Position {
 	}
 
 	for _, d := range directions {
-		for step := 1; step <= defaultBombRange; step++ {
+		for step := 1; step <= bombRange; step++ {
 			pos := Position{
 				X: origin.X + d.X*step,
 				Y: origin.Y + d.Y*step,
 			}
-			if pos.X < 0 || pos.X >= h.State.Field.Width || pos.Y < 0 || pos.Y >= h.State.Field.Height {
+			if pos.X < 0 || pos.X >= field.Width || pos.Y < 0 || pos.Y >= field.Height {
 				break
 			}
 
-			cell := h.State.Field.CellAt(pos)
+			cell := field.CellAt(pos)
 			if cell == Wall {
 				break
 			}
@@ -0,0 +1,58 @@
+package bombahead
+
+import "testing"
+
+// benchmarkState is a 15x13 board in the middle of a game, with a bomb about to go off
+func benchmarkState() *GameState {
+	return MustParseASCII(`
+		###############
+		#A....+.......#
+		#.#.#.#+#.#.#.#
+		#.....2.......#
+		#.#.#.#.#+#.#.#
+		#+.....3......#
+		#.#.#.#.#.#.#.#
+		#.....1.......#
+		#.#.#.#.#.#.#+#
+		#...+.........#
+		#.#.#.#.#.#.#.#
+		#...+.......B.#
+		###############
+	`)
+}
+
+func BenchmarkGetNextActionTowards(b *testing.B) {
+	state := benchmarkState()
+	h := NewGameHelpers(state)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		h.GetNextActionTowards(Position{X: 1, Y: 1}, Position{X: 12, Y: 11})
+	}
+}
+
+func BenchmarkGetNearestSafePosition(b *testing.B) {
+	state := benchmarkState()
+	h := NewGameHelpers(state)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		h.GetNearestSafePosition(Position{X: 6, Y: 7})
+	}
+}
+
+func BenchmarkIsSafe(b *testing.B) {
+	state := benchmarkState()
+	h := NewGameHelpers(state)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		h.IsSafe(Position{X: 7, Y: 9})
+	}
+}
+
+func BenchmarkFindNearestBox(b *testing.B) {
+	state := benchmarkState()
+	h := NewGameHelpers(state)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		h.FindNearestBox(Position{X: 12, Y: 11})
+	}
+}
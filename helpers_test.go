@@ -1,6 +1,9 @@
 package bombahead
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 func TestIsWalkable(t *testing.T) {
 	t.Parallel()
@@ -206,3 +209,116 @@ func TestFindNearestBox(t *testing.T) {
 		t.Fatal("expected no box to be found")
 	}
 }
+
+func TestBlastCells(t *testing.T) {
+	t.Parallel()
+
+	field := Field{
+		Width:  5,
+		Height: 3,
+		Cells: []CellType{
+			Air, Air, Air, Air, Air,
+			Air, Wall, Air, Box, Air,
+			Air, Air, Air, Air, Air,
+		},
+	}
+
+	got := BlastCells(field, Position{X: 2, Y: 1}, 3)
+	want := []Position{
+		{X: 2, Y: 1},
+		{X: 2, Y: 0},
+		{X: 3, Y: 1},
+		{X: 2, Y: 2},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("BlastCells() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("BlastCells()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestDangerPositions(t *testing.T) {
+	t.Parallel()
+
+	state := MustParseASCII(`
+		.....
+		.#...
+		..1..
+		*....
+	`)
+	got := NewGameHelpers(state).DangerPositions()
+	want := []Position{
+		{X: 2, Y: 0},
+		{X: 2, Y: 1},
+		{X: 0, Y: 2}, {X: 1, Y: 2}, {X: 2, Y: 2}, {X: 3, Y: 2}, {X: 4, Y: 2},
+		{X: 0, Y: 3}, {X: 2, Y: 3},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("DangerPositions() = %v, want %v", got, want)
+	}
+}
+
+func TestDangerPositions_OutsideField(t *testing.T) {
+	t.Parallel()
+
+	// Lenient parsing keeps bombs and explosions outside the field; an explosion still sets off such a bomb
+	state := MustParseASCII(`
+		...
+		...
+		...
+	`)
+	state.Bombs = []Bomb{{Pos: Position{X: -1, Y: 1}, Fuse: 3}}
+	state.Explosions = []Position{{X: -1, Y: 1}}
+	h := NewGameHelpers(state)
+
+	want := []Position{{X: -1, Y: 1}, {X: 0, Y: 1}, {X: 1, Y: 1}}
+	if got := h.DangerPositions(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("DangerPositions() = %v, want %v", got, want)
+	}
+	if h.IsSafe(Position{X: 1, Y: 1}) {
+		t.Fatal("IsSafe(1,1) = true, want false in the blast of the bomb outside the field")
+	}
+}
+
+func TestBombRange(t *testing.T) {
+	t.Parallel()
+
+	state := MustParseASCII(`
+		A.......
+		A p1 range=4
+		B p2 at=7,0
+		bomb 1,0 fuse=1 owner=p1
+		bomb 2,0 fuse=1 owner=p1 range=1
+		bomb 3,0 fuse=1 owner=p2
+		bomb 4,0 fuse=1
+	`)
+	h := NewGameHelpers(state)
+	for i, want := range []int{4, 1, defaultBombRange, defaultBombRange} {
+		if got := h.BombRange(state.Bombs[i]); got != want {
+			t.Fatalf("BombRange(bombs[%d]) = %d, want %d", i, got, want)
+		}
+	}
+}
+
+func TestIsSafe_UsesBombRange(t *testing.T) {
+	t.Parallel()
+
+	state := MustParseASCII(`
+		A......
+		A p1 range=5 at=0,0
+		bomb 0,0 fuse=1 owner=p1
+	`)
+	h := NewGameHelpers(state)
+	if h.IsSafe(Position{X: 5, Y: 0}) {
+		t.Fatal("expected cell at the owner's range to be unsafe")
+	}
+	if !h.IsSafe(Position{X: 6, Y: 0}) {
+		t.Fatal("expected cell beyond the owner's range to stay safe")
+	}
+	if _, hit := h.DangerMap().ETA(Position{X: 5, Y: 0}); !hit {
+		t.Fatal("expected DangerMap to use the owner's range")
+	}
+}
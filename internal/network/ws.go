@@ -1,7 +1,9 @@
 package network
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -9,6 +11,15 @@ import (
 	"github.com/gorilla/websocket"
 )
 
+var (
+	// ErrUnauthorized is returned when the server rejects the client credentials.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrClosed is returned when the server closes the websocket connection.
+	ErrClosed = errors.New("connection closed by server")
+	// ErrInvalidEnvelope is returned when a received frame is not a valid envelope.
+	ErrInvalidEnvelope = errors.New("invalid message envelope")
+)
+
 // Message represents the old bombahead websocket envelope.
 type Message struct {
 	Type    string          `json:"type"`
@@ -22,6 +33,12 @@ type WsClient struct {
 
 // Connect establishes a connection to the game server.
 func Connect(serverURL string, token string) (*WsClient, error) {
+	return ConnectContext(context.Background(), serverURL, token)
+}
+
+// ConnectContext establishes a connection to the game server and aborts the
+// handshake when ctx is cancelled.
+func ConnectContext(ctx context.Context, serverURL string, token string) (*WsClient, error) {
 	u, err := url.Parse(serverURL)
 	if err != nil {
 		return nil, fmt.Errorf("invalid URL: %w", err)
@@ -32,8 +49,11 @@ func Connect(serverURL string, token string) (*WsClient, error) {
 		header.Add("Authorization", "Bearer "+token)
 	}
 
-	c, _, err := websocket.DefaultDialer.Dial(u.String(), header)
+	c, resp, err := websocket.DefaultDialer.DialContext(ctx, u.String(), header)
 	if err != nil {
+		if resp != nil && (resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden) {
+			return nil, fmt.Errorf("%w: %s", ErrUnauthorized, resp.Status)
+		}
 		return nil, fmt.Errorf("failed to dial: %w", err)
 	}
 
@@ -44,12 +64,19 @@ func Connect(serverURL string, token string) (*WsClient, error) {
 func (w *WsClient) ReadMessage() (*Message, error) {
 	_, message, err := w.conn.ReadMessage()
 	if err != nil {
+		var closeErr *websocket.CloseError
+		if errors.As(err, &closeErr) {
+			if closeErr.Code == websocket.ClosePolicyViolation {
+				return nil, fmt.Errorf("%w: %w", ErrUnauthorized, err)
+			}
+			return nil, fmt.Errorf("%w: %w", ErrClosed, err)
+		}
 		return nil, err
 	}
 
 	var m Message
 	if err := json.Unmarshal(message, &m); err != nil {
-		return nil, fmt.Errorf("invalid message envelope: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidEnvelope, err)
 	}
 
 	return &m, nil
@@ -2,6 +2,7 @@ package network
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -101,3 +102,81 @@ func TestWsClient_CloseNilConnection(t *testing.T) {
 		t.Fatalf("Close() on nil conn should be nil, got %v", err)
 	}
 }
+
+func TestConnect_Unauthorized(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	_, err := Connect("ws"+strings.TrimPrefix(server.URL, "http"), "bad")
+	if !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("Connect() error = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestWsClient_ReadMessageClassifiesErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		frame func(conn *websocket.Conn) error
+		want  error
+	}{
+		{
+			name: "normal close",
+			frame: func(conn *websocket.Conn) error {
+				return conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			},
+			want: ErrClosed,
+		},
+		{
+			name: "policy violation",
+			frame: func(conn *websocket.Conn) error {
+				return conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "bad token"))
+			},
+			want: ErrUnauthorized,
+		},
+		{
+			name: "invalid envelope",
+			frame: func(conn *websocket.Conn) error {
+				return conn.WriteMessage(websocket.TextMessage, []byte("{"))
+			},
+			want: ErrInvalidEnvelope,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			upgrader := websocket.Upgrader{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				conn, err := upgrader.Upgrade(w, r, nil)
+				if err != nil {
+					t.Errorf("upgrade failed: %v", err)
+					return
+				}
+				defer conn.Close()
+				if err := tc.frame(conn); err != nil {
+					t.Errorf("server write failed: %v", err)
+				}
+				_, _, _ = conn.ReadMessage()
+			}))
+			defer server.Close()
+
+			client, err := Connect("ws"+strings.TrimPrefix(server.URL, "http"), "")
+			if err != nil {
+				t.Fatalf("Connect() error = %v", err)
+			}
+			defer client.Close()
+
+			if _, err := client.ReadMessage(); !errors.Is(err, tc.want) {
+				t.Fatalf("ReadMessage() error = %v, want %v", err, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package bombahead
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// GameStart is passed to GameStartListener when a match begins
+// Fields the server does not send stay empty
+type GameStart struct {
+	GameID  string   `json:"gameId"`
+	Players []Player `json:"players"`
+}
+
+// GameEnd is passed to GameEndListener when the server sends the players back to the lobby
+type GameEnd struct {
+	// Winner is the ID of the winning player, if the server reports one
+	Winner string `json:"winner"`
+	// Scores maps player IDs to their final score, if the server reports them
+	Scores map[string]int `json:"scores"`
+	// LastState is the last state received during the game, or nil if none arrived
+	LastState *GameState `json:"-"`
+}
+
+// Lobby is the lobby state sent with update_lobby
+type Lobby struct {
+	Players []LobbyPlayer `json:"players"`
+}
+
+// LobbyPlayer is one client waiting in the lobby
+type LobbyPlayer struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	IsReady bool   `json:"isReady"`
+	Score   int    `json:"score"`
+}
+
+// GameStartListener can be implemented by a Bot to reset per-game memory
+// A bot call that missed its move deadline has returned before OnGameStart is called
+type GameStartListener interface {
+	OnGameStart(start GameStart)
+}
+
+// GameEndListener can be implemented by a Bot to learn the result of a game
+// A bot call that missed its move deadline has returned before OnGameEnd is called
+type GameEndListener interface {
+	OnGameEnd(end GameEnd)
+}
+
+// LobbyListener can be implemented by a Bot to observe lobby updates
+type LobbyListener interface {
+	OnLobbyUpdate(lobby Lobby)
+}
+
+// ServerErrorListener can be implemented by a Bot to receive error messages from the server
+type ServerErrorListener interface {
+	OnServerError(message string)
+}
+
+// decodeOptionalPayload unmarshals data into v, treating an absent payload as empty
+func decodeOptionalPayload(data json.RawMessage, v any) error {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")) {
+		return nil
+	}
+	return json.Unmarshal(trimmed, v)
+}
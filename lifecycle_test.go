@@ -0,0 +1,152 @@
+package bombahead
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+type lifecycleBot struct {
+	constantBot
+	events []string
+	lobby  Lobby
+	start  GameStart
+	end    GameEnd
+}
+
+func (b *lifecycleBot) OnLobbyUpdate(lobby Lobby) {
+	b.events = append(b.events, "lobby")
+	b.lobby = lobby
+}
+
+func (b *lifecycleBot) OnGameStart(start GameStart) {
+	b.events = append(b.events, "start")
+	b.start = start
+}
+
+func (b *lifecycleBot) OnGameEnd(end GameEnd) {
+	b.events = append(b.events, "end")
+	b.end = end
+}
+
+func (b *lifecycleBot) OnServerError(message string) {
+	b.events = append(b.events, "error:"+message)
+}
+
+func TestRunContext_InvokesLifecycleListeners(t *testing.T) {
+	t.Parallel()
+
+	reready := make(chan playerStatusUpdatePayload, 1)
+	url := startTestServer(t, func(conn *websocket.Conn) {
+		readTestMessage(t, conn)
+		writeTestMessage(t, conn, msgWelcome, welcomePayload{ClientID: "p1"})
+		writeTestMessage(t, conn, msgUpdateLobby, Lobby{Players: []LobbyPlayer{{ID: "p1", Name: "bot", IsReady: true}}})
+		writeTestMessage(t, conn, msgGameStart, nil)
+		writeTestMessage(t, conn, msgClassicState, map[string]any{
+			"players": []Player{{ID: "p1", Score: 7}},
+			"field":   map[string]any{"width": 1, "height": 1, "field": []string{"AIR"}},
+		})
+		readTestMessage(t, conn)
+		writeTestMessage(t, conn, msgServerError, errorPayload{Message: "slow down"})
+		writeTestMessage(t, conn, msgBackToLobby, map[string]any{"winner": "p1"})
+
+		var status playerStatusUpdatePayload
+		if err := json.Unmarshal(readTestMessage(t, conn).Payload, &status); err != nil {
+			t.Errorf("unmarshal ready payload: %v", err)
+		}
+		reready <- status
+		_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	})
+
+	bot := &lifecycleBot{}
+	if err := RunContext(context.Background(), bot, WithURL(url)); !errors.Is(err, ErrServerClosed) {
+		t.Fatalf("RunContext() error = %v, want ErrServerClosed", err)
+	}
+
+	want := []string{"lobby", "start", "error:slow down", "end"}
+	if len(bot.events) != len(want) {
+		t.Fatalf("events = %v, want %v", bot.events, want)
+	}
+	for i := range want {
+		if bot.events[i] != want[i] {
+			t.Fatalf("events = %v, want %v", bot.events, want)
+		}
+	}
+
+	if len(bot.lobby.Players) != 1 || bot.lobby.Players[0].Name != "bot" || !bot.lobby.Players[0].IsReady {
+		t.Fatalf("lobby = %+v, want one ready player named bot", bot.lobby)
+	}
+	if bot.end.Winner != "p1" {
+		t.Fatalf("end.Winner = %q, want %q", bot.end.Winner, "p1")
+	}
+	if bot.end.LastState == nil || bot.end.LastState.Me == nil || bot.end.LastState.Me.Score != 7 {
+		t.Fatalf("end.LastState = %+v, want last received state", bot.end.LastState)
+	}
+	if status := <-reready; !status.IsReady {
+		t.Fatal("expected the client to re-ready after back_to_lobby")
+	}
+}
+
+// slowEndBot answers too late and records whether its call had returned by OnGameEnd
+type slowEndBot struct {
+	returned    bool
+	endReturned bool
+}
+
+func (b *slowEndBot) GetNextMove(*GameState, *GameHelpers) Action {
+	time.Sleep(50 * time.Millisecond)
+	b.returned = true
+	return DoNothing
+}
+
+func (b *slowEndBot) OnGameEnd(GameEnd) {
+	b.endReturned = b.returned
+}
+
+func TestRunContext_GameEndWaitsForLateMove(t *testing.T) {
+	t.Parallel()
+
+	url := startTestServer(t, func(conn *websocket.Conn) {
+		readTestMessage(t, conn)
+		writeTestMessage(t, conn, msgWelcome, welcomePayload{ClientID: "p1"})
+		writeTestMessage(t, conn, msgGameStart, nil)
+		writeTestMessage(t, conn, msgClassicState, map[string]any{
+			"players": []Player{{ID: "p1"}},
+			"field":   map[string]any{"width": 1, "height": 1, "field": []string{"AIR"}},
+		})
+		readTestMessage(t, conn)
+		writeTestMessage(t, conn, msgBackToLobby, nil)
+		readTestMessage(t, conn)
+		_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	})
+
+	bot := &slowEndBot{}
+	if err := RunContext(context.Background(), bot, WithURL(url), WithMoveDeadline(5*time.Millisecond)); !errors.Is(err, ErrServerClosed) {
+		t.Fatalf("RunContext() error = %v, want ErrServerClosed", err)
+	}
+	if !bot.endReturned {
+		t.Fatal("OnGameEnd ran before the late GetNextMove returned")
+	}
+}
+
+func TestDecodeOptionalPayload(t *testing.T) {
+	t.Parallel()
+
+	var start GameStart
+	for _, raw := range []string{"", "null", "  "} {
+		if err := decodeOptionalPayload(json.RawMessage(raw), &start); err != nil {
+			t.Fatalf("decodeOptionalPayload(%q) error = %v", raw, err)
+		}
+	}
+
+	if err := decodeOptionalPayload(json.RawMessage(`{"gameId":"g1"}`), &start); err != nil || start.GameID != "g1" {
+		t.Fatalf("decodeOptionalPayload() = %+v, %v, want gameId g1", start, err)
+	}
+	if err := decodeOptionalPayload(json.RawMessage(`[`), &start); err == nil {
+		t.Fatal("decodeOptionalPayload() expected error for invalid JSON")
+	}
+}
@@ -14,17 +14,34 @@ func (p Position) DistanceTo(other Position) int {
 }
 
 // Player represents a bot in the game
+// BombRange, MaxBombs and Speed are zero when the server does not send them
 type Player struct {
 	ID     string   `json:"id"`
 	Pos    Position `json:"pos"`
 	Health int      `json:"health"`
 	Score  int      `json:"score"`
+	// BombRange is the blast range of the player's bombs
+	BombRange int `json:"bombRange,omitempty"`
+	// MaxBombs is the number of bombs the player may have on the field at once
+	MaxBombs int `json:"maxBombs,omitempty"`
+	// Speed is the movement speed, as reported by the server
+	Speed int `json:"speed,omitempty"`
 }
 
 // Bomb represents a bomb placed on the field
+// Owner is the ID of the player who placed it, empty if the server does not send it
+// Range is the blast range, zero if the server does not send it
 type Bomb struct {
-	Pos  Position `json:"pos"`
-	Fuse int      `json:"fuse"`
+	Pos   Position `json:"pos"`
+	Fuse  int      `json:"fuse"`
+	Owner string   `json:"owner,omitempty"`
+	Range int      `json:"range,omitempty"`
+}
+
+// Item is a power-up lying on the field, collected by walking onto it
+type Item struct {
+	Pos  Position    `json:"pos"`
+	Kind PowerUpKind `json:"kind"`
 }
 
 // Field represents the game board
@@ -48,12 +65,17 @@ func (f Field) CellAt(pos Position) CellType {
 }
 
 // GameState contains all information about the current state of the game
+// CurrentTick comes from the server or, if it sends none, from a client-side counter.
+// Round and MaxTicks are zero when the server does not send them
 type GameState struct {
 	CurrentTick int        `json:"currentTick"`
+	Round       int        `json:"round"`
+	MaxTicks    int        `json:"maxTicks"`
 	Me          *Player    `json:"me"`
 	Opponents   []Player   `json:"opponents"`
 	Players     []Player   `json:"players"`
 	Field       Field      `json:"field"`
 	Bombs       []Bomb     `json:"bombs"`
 	Explosions  []Position `json:"explosions"`
+	Items       []Item     `json:"items"`
 }
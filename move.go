@@ -0,0 +1,229 @@
+package bombahead
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"runtime/debug"
+	"time"
+)
+
+// ContextBot can be implemented by a Bot that wants to observe the move deadline
+// When a deadline is configured, ctx expires when the fallback action is sent
+type ContextBot interface {
+	Bot
+	GetNextMoveContext(ctx context.Context, state *GameState, helpers *GameHelpers) Action
+}
+
+// FallbackFunc computes the action that is sent when the bot misses the move deadline
+type FallbackFunc func(state *GameState, helpers *GameHelpers) Action
+
+// SafeFallback moves one step towards the nearest safe position
+// It is the default FallbackFunc
+func SafeFallback(state *GameState, helpers *GameHelpers) Action {
+	if state == nil || state.Me == nil {
+		return DoNothing
+	}
+	me := state.Me.Pos
+	target := helpers.GetNearestSafePosition(me)
+	if target == me {
+		return DoNothing
+	}
+
+	// The player may be standing on its own bomb, so the start must not need to be walkable
+	return helpers.firstStepTowards(me, target, true)
+}
+
+// WithMoveDeadline limits how long the bot may take to choose an action
+// Choose a value below the server tick interval, so the fallback still arrives in time.
+// A late bot keeps running in the background and is not called again until it returns;
+// its result is discarded, though a panic in it counts in MoveStats.Panics.
+// OnGameStart and OnGameEnd wait for such a call, OnMoveStats does not. Zero disables the deadline
+func WithMoveDeadline(d time.Duration) Option {
+	return func(c *config) {
+		c.moveDeadline = d
+	}
+}
+
+// WithFallback replaces SafeFallback as the action sent for late moves
+func WithFallback(fallback FallbackFunc) Option {
+	return func(c *config) {
+		if fallback != nil {
+			c.fallback = fallback
+		}
+	}
+}
+
+// MoveStats summarizes how quickly the bot answered
+type MoveStats struct {
+	// Moves is the number of actions sent, including fallbacks
+	Moves int
+	// Late is the number of ticks where the bot missed the deadline
+	Late int
+	// Busy is the number of ticks where the bot was still working on an earlier tick
+	Busy int
+	// Unpublished is the number of ticks where an AnytimeBot returned in time without publishing an action
+	Unpublished int
+	// Panics is the number of ticks where the bot panicked, including calls that had already missed the deadline
+	// Those are counted once the client notices the call has returned
+	Panics int
+	// LastLatency is the time the bot needed for the most recent answered tick
+	LastLatency time.Duration
+	// MaxLatency is the longest time the bot needed for an answered tick
+	MaxLatency time.Duration
+	// TotalLatency is the sum of all answered tick latencies
+	TotalLatency time.Duration
+
+	// latePanics is the part of Panics whose tick was already counted as Late
+	latePanics int
+}
+
+// Fallbacks returns the number of ticks answered with the fallback action
+func (s MoveStats) Fallbacks() int {
+	return s.Late + s.Busy + s.Unpublished + s.Panics - s.latePanics
+}
+
+// AverageLatency returns the mean latency of ticks the bot answered in time
+func (s MoveStats) AverageLatency() time.Duration {
+	answered := s.Moves - s.Fallbacks()
+	if answered <= 0 {
+		return 0
+	}
+	return s.TotalLatency / time.Duration(answered)
+}
+
+// MoveStatsListener can be implemented by a Bot to receive MoveStats after every sent action
+// With a move deadline, OnMoveStats may run while a late GetNextMove is still working, so shared bot state needs a lock
+type MoveStatsListener interface {
+	OnMoveStats(stats MoveStats)
+}
+
+// turn is one request for a move
+type turn struct {
+	state   *GameState
+	helpers *GameHelpers
+	// payload is the raw classic_state the state was parsed from
+	payload  json.RawMessage
+	clientID string
+}
+
+// botResult is the outcome of one bot call
+type botResult struct {
+	action   Action
+	panicked bool
+}
+
+// decide asks the bot for the next action, enforcing the configured deadline
+func (r *runner) decide(ctx context.Context, t turn) Action {
+	if ab, ok := r.bot.(AnytimeBot); ok {
+		return r.decideAnytime(ctx, ab, t)
+	}
+
+	if r.cfg.moveDeadline <= 0 {
+		start := time.Now()
+		res := r.callBot(ctx, t)
+		if res.panicked {
+			return r.panicFallback(t)
+		}
+		r.recordLatency(time.Since(start))
+		return res.action
+	}
+
+	if r.stillBusy() {
+		r.stats.Busy++
+		log.Printf("Bot still busy with an earlier tick, sending fallback")
+		return r.cfg.fallback(t.state, t.helpers)
+	}
+
+	moveCtx, cancel := context.WithTimeout(ctx, r.cfg.moveDeadline)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan botResult, 1)
+	go func() {
+		done <- r.callBot(moveCtx, t)
+	}()
+
+	select {
+	case res := <-done:
+		if res.panicked {
+			return r.panicFallback(t)
+		}
+		r.recordLatency(time.Since(start))
+		return res.action
+	case <-moveCtx.Done():
+		r.pending = done
+		r.stats.Late++
+		log.Printf("Bot missed the %s move deadline, sending fallback", r.cfg.moveDeadline)
+		// The bot may still be using helpers, so the fallback gets its own instance
+		return r.cfg.fallback(t.state, NewGameHelpers(t.state))
+	}
+}
+
+// stillBusy reports whether a bot call that missed its deadline is still running
+func (r *runner) stillBusy() bool {
+	if r.pending == nil {
+		return false
+	}
+	select {
+	case res := <-r.pending:
+		r.finishPending(res)
+		return false
+	default:
+		return true
+	}
+}
+
+// waitPending blocks until a bot call that missed its deadline has returned
+func (r *runner) waitPending() {
+	if r.pending != nil {
+		r.finishPending(<-r.pending)
+	}
+}
+
+// finishPending records the result of a late bot call; its action is discarded, but a panic still counts
+func (r *runner) finishPending(res botResult) {
+	r.pending = nil
+	if res.panicked {
+		r.stats.Panics++
+		r.stats.latePanics++
+	}
+}
+
+// callBot invokes the bot and recovers a panic inside it
+// It may run on its own goroutine, so it must not touch mutable runner state
+func (r *runner) callBot(ctx context.Context, t turn) (res botResult) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			reportPanic(r.cfg.panicDumpDir, t, rec, debug.Stack())
+			res = botResult{panicked: true}
+		}
+	}()
+
+	if cb, ok := r.bot.(ContextBot); ok {
+		return botResult{action: cb.GetNextMoveContext(ctx, t.state, t.helpers)}
+	}
+	return botResult{action: r.bot.GetNextMove(t.state, t.helpers)}
+}
+
+// panicFallback counts a recovered panic and returns the fallback action
+func (r *runner) panicFallback(t turn) Action {
+	r.stats.Panics++
+	return r.cfg.fallback(t.state, NewGameHelpers(t.state))
+}
+
+func (r *runner) recordLatency(d time.Duration) {
+	r.stats.LastLatency = d
+	r.stats.TotalLatency += d
+	if d > r.stats.MaxLatency {
+		r.stats.MaxLatency = d
+	}
+}
+
+// moveSent updates the statistics after an action has been sent
+func (r *runner) moveSent() {
+	r.stats.Moves++
+	if l, ok := r.bot.(MoveStatsListener); ok {
+		l.OnMoveStats(r.stats)
+	}
+}
@@ -0,0 +1,163 @@
+package bombahead
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type blockingBot struct {
+	release chan struct{}
+	calls   chan struct{}
+}
+
+func (b *blockingBot) GetNextMove(*GameState, *GameHelpers) Action {
+	b.calls <- struct{}{}
+	<-b.release
+	return PlaceBomb
+}
+
+// latePanicBot panics once release is closed, after it has missed its deadline
+type latePanicBot struct {
+	release chan struct{}
+}
+
+func (b *latePanicBot) GetNextMove(*GameState, *GameHelpers) Action {
+	<-b.release
+	panic("late")
+}
+
+type deadlineBot struct {
+	hasDeadline bool
+}
+
+func (b *deadlineBot) GetNextMove(*GameState, *GameHelpers) Action {
+	return DoNothing
+}
+
+func (b *deadlineBot) GetNextMoveContext(ctx context.Context, _ *GameState, _ *GameHelpers) Action {
+	_, b.hasDeadline = ctx.Deadline()
+	return MoveLeft
+}
+
+func openFieldState() *GameState {
+	cells := make([]CellType, 25)
+	for i := range cells {
+		cells[i] = Air
+	}
+	me := Player{ID: "me", Pos: Position{X: 2, Y: 2}}
+	return &GameState{
+		Me:      &me,
+		Players: []Player{me},
+		Field:   Field{Width: 5, Height: 5, Cells: cells},
+		Bombs:   []Bomb{{Pos: Position{X: 2, Y: 2}, Fuse: 1}},
+	}
+}
+
+func TestSafeFallback(t *testing.T) {
+	t.Parallel()
+
+	state := openFieldState()
+	if got := SafeFallback(state, NewGameHelpers(state)); got != MoveUp {
+		t.Fatalf("SafeFallback() = %q, want %q", got, MoveUp)
+	}
+	if got := SafeFallback(&GameState{}, NewGameHelpers(&GameState{})); got != DoNothing {
+		t.Fatalf("SafeFallback(no player) = %q, want %q", got, DoNothing)
+	}
+}
+
+func TestDecide_WithoutDeadline(t *testing.T) {
+	t.Parallel()
+
+	bot := &deadlineBot{}
+	r := &runner{bot: bot, cfg: newConfig(nil)}
+	state := openFieldState()
+
+	if got := r.decide(context.Background(), turn{state: state, helpers: NewGameHelpers(state)}); got != MoveLeft {
+		t.Fatalf("decide() = %q, want %q", got, MoveLeft)
+	}
+	if bot.hasDeadline {
+		t.Fatal("expected no deadline on the bot context")
+	}
+}
+
+func TestDecide_DeadlineFallbackAndBusy(t *testing.T) {
+	t.Parallel()
+
+	bot := &blockingBot{release: make(chan struct{}), calls: make(chan struct{}, 4)}
+	r := &runner{bot: bot, cfg: newConfig([]Option{
+		WithMoveDeadline(10 * time.Millisecond),
+		WithFallback(func(*GameState, *GameHelpers) Action { return MoveDown }),
+	})}
+	state := openFieldState()
+
+	if got := r.decide(context.Background(), turn{state: state, helpers: NewGameHelpers(state)}); got != MoveDown {
+		t.Fatalf("decide(late) = %q, want fallback %q", got, MoveDown)
+	}
+	if got := r.decide(context.Background(), turn{state: state, helpers: NewGameHelpers(state)}); got != MoveDown {
+		t.Fatalf("decide(busy) = %q, want fallback %q", got, MoveDown)
+	}
+	if len(bot.calls) != 1 {
+		t.Fatalf("bot called %d times while busy, want 1", len(bot.calls))
+	}
+	if r.stats.Late != 1 || r.stats.Busy != 1 || r.stats.Fallbacks() != 2 {
+		t.Fatalf("stats = %+v, want one late and one busy tick", r.stats)
+	}
+
+	close(bot.release)
+	r.waitPending()
+	if got := r.decide(context.Background(), turn{state: state, helpers: NewGameHelpers(state)}); got != PlaceBomb {
+		t.Fatalf("decide(after release) = %q, want %q", got, PlaceBomb)
+	}
+}
+
+func TestDecide_LatePanicCounts(t *testing.T) {
+	t.Parallel()
+
+	bot := &latePanicBot{release: make(chan struct{})}
+	r := &runner{bot: bot, cfg: newConfig([]Option{
+		WithMoveDeadline(10 * time.Millisecond),
+		WithPanicDumpDir(t.TempDir()),
+	})}
+	state := openFieldState()
+
+	r.decide(context.Background(), turn{state: state, helpers: NewGameHelpers(state)})
+	close(bot.release)
+	r.waitPending()
+	if r.stats.Late != 1 || r.stats.Panics != 1 {
+		t.Fatalf("stats = %+v, want one late tick and one panic", r.stats)
+	}
+	if got := r.stats.Fallbacks(); got != 1 {
+		t.Fatalf("Fallbacks() = %d, want 1", got)
+	}
+}
+
+func TestDecide_ContextBotSeesDeadline(t *testing.T) {
+	t.Parallel()
+
+	bot := &deadlineBot{}
+	r := &runner{bot: bot, cfg: newConfig([]Option{WithMoveDeadline(time.Second)})}
+	state := openFieldState()
+
+	if got := r.decide(context.Background(), turn{state: state, helpers: NewGameHelpers(state)}); got != MoveLeft {
+		t.Fatalf("decide() = %q, want %q", got, MoveLeft)
+	}
+	if !bot.hasDeadline {
+		t.Fatal("expected the bot context to carry the move deadline")
+	}
+	if r.stats.Late != 0 {
+		t.Fatalf("stats.Late = %d, want 0", r.stats.Late)
+	}
+}
+
+func TestMoveStatsAverageLatency(t *testing.T) {
+	t.Parallel()
+
+	stats := MoveStats{Moves: 4, Late: 1, Busy: 1, TotalLatency: 30 * time.Millisecond}
+	if got := stats.AverageLatency(); got != 15*time.Millisecond {
+		t.Fatalf("AverageLatency() = %s, want 15ms", got)
+	}
+	if got := (MoveStats{}).AverageLatency(); got != 0 {
+		t.Fatalf("AverageLatency(empty) = %s, want 0", got)
+	}
+}
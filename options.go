@@ -0,0 +1,65 @@
+package bombahead
+
+import (
+	"os"
+	"time"
+)
+
+const defaultServerURL = "ws://localhost:8038/ws"
+
+// Option configures the client started by RunContext
+type Option func(*config)
+
+type config struct {
+	url          string
+	token        string
+	reconnect    *ReconnectPolicy
+	moveDeadline time.Duration
+	fallback     FallbackFunc
+	panicDumpDir string
+	strict       bool
+	dial         Dialer
+	recorder     *Recorder
+}
+
+// WithURL overrides the game server WebSocket URL
+// By default BOMBAHEAD_WS_URL is used, falling back to ws://localhost:8038/ws
+func WithURL(url string) Option {
+	return func(c *config) {
+		c.url = url
+	}
+}
+
+// WithToken overrides the authentication token sent to the server
+// By default BOMBAHEAD_TOKEN or BOMBERMAN_CLIENT_AUTH_TOKEN is used
+func WithToken(token string) Option {
+	return func(c *config) {
+		c.token = token
+	}
+}
+
+func newConfig(opts []Option) config {
+	cfg := config{
+		url:      os.Getenv("BOMBAHEAD_WS_URL"),
+		token:    os.Getenv("BOMBAHEAD_TOKEN"),
+		fallback: SafeFallback,
+		dial:     WebSocketDialer,
+	}
+	if cfg.url == "" {
+		cfg.url = defaultServerURL
+	}
+	if cfg.token == "" {
+		cfg.token = os.Getenv("BOMBERMAN_CLIENT_AUTH_TOKEN")
+	}
+	if cfg.token == "" {
+		cfg.token = "dev-token-local"
+	}
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(&cfg)
+		}
+	}
+
+	return cfg
+}
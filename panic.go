@@ -0,0 +1,108 @@
+package bombahead
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// WithPanicDumpDir writes a PanicDump file into dir whenever the bot panics
+// The directory is created if it does not exist
+func WithPanicDumpDir(dir string) Option {
+	return func(c *config) {
+		c.panicDumpDir = dir
+	}
+}
+
+// PanicDump is the file written by WithPanicDumpDir
+// It keeps the raw classic_state payload, so the exact GameState can be rebuilt in a test
+type PanicDump struct {
+	ClientID string          `json:"clientId"`
+	Tick     int             `json:"tick"`
+	Panic    string          `json:"panic"`
+	Stack    string          `json:"stack"`
+	Time     time.Time       `json:"time"`
+	Payload  json.RawMessage `json:"payload"`
+}
+
+// State rebuilds the GameState the bot received when it panicked
+func (d *PanicDump) State() (*GameState, error) {
+	return ParseClassicState(d.Payload, d.ClientID)
+}
+
+// LoadPanicDump reads a file written by WithPanicDumpDir
+func LoadPanicDump(path string) (*PanicDump, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read panic dump: %w", err)
+	}
+
+	var dump PanicDump
+	if err := json.Unmarshal(data, &dump); err != nil {
+		return nil, fmt.Errorf("unmarshal panic dump: %w", err)
+	}
+	return &dump, nil
+}
+
+// reportPanic logs a recovered bot panic and writes a dump when dir is set
+func reportPanic(dir string, t turn, rec any, stack []byte) {
+	tick := 0
+	if t.state != nil {
+		tick = t.state.CurrentTick
+	}
+	log.Printf("Bot panicked on tick %d (%s): %v\n%s", tick, summarizeState(t.state), rec, stack)
+
+	if dir == "" {
+		return
+	}
+
+	dump := PanicDump{
+		ClientID: t.clientID,
+		Tick:     tick,
+		Panic:    fmt.Sprint(rec),
+		Stack:    string(stack),
+		Time:     time.Now(),
+		Payload:  t.payload,
+	}
+	path, err := writePanicDump(dir, dump)
+	if err != nil {
+		log.Printf("Failed to write panic dump: %v", err)
+		return
+	}
+	log.Printf("Wrote panic dump to %s", path)
+}
+
+func writePanicDump(dir string, dump PanicDump) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(dump, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	name := fmt.Sprintf("panic-tick%d-%d.json", dump.Tick, dump.Time.UnixNano())
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// summarizeState returns a one-line description of state for log output
+func summarizeState(state *GameState) string {
+	if state == nil {
+		return "no state"
+	}
+
+	me := "unknown"
+	if state.Me != nil {
+		me = fmt.Sprintf("%s at (%d,%d) hp=%d", state.Me.ID, state.Me.Pos.X, state.Me.Pos.Y, state.Me.Health)
+	}
+	return fmt.Sprintf("me=%s players=%d bombs=%d explosions=%d field=%dx%d",
+		me, len(state.Players), len(state.Bombs), len(state.Explosions), state.Field.Width, state.Field.Height)
+}
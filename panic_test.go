@@ -0,0 +1,125 @@
+package bombahead
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type panickingBot struct{}
+
+func (panickingBot) GetNextMove(*GameState, *GameHelpers) Action {
+	var missing *Player
+	return Action(missing.ID)
+}
+
+type panickingSearchBot struct {
+	panickingBot
+}
+
+func (panickingSearchBot) SearchNextMove(_ context.Context, _ *GameState, _ *GameHelpers, sink ActionSink) {
+	sink.Publish(PlaceBomb)
+	panic("search exploded")
+}
+
+func TestDecide_RecoversPanicAndWritesDump(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	payload := []byte(`{
+		"players":[{"id":"p1","pos":{"x":1,"y":1},"health":3,"score":0}],
+		"field":{"width":3,"height":3,"field":["AIR","AIR","AIR","AIR","AIR","AIR","AIR","AIR","AIR"]},
+		"bombs":[{"pos":{"x":1,"y":1},"fuse":1}],
+		"explosions":[]
+	}`)
+	state, err := ParseClassicState(payload, "p1")
+	if err != nil {
+		t.Fatalf("ParseClassicState() error = %v", err)
+	}
+
+	r := &runner{bot: panickingBot{}, cfg: newConfig([]Option{
+		WithPanicDumpDir(dir),
+		WithFallback(func(*GameState, *GameHelpers) Action { return MoveUp }),
+	})}
+	got := r.decide(context.Background(), turn{state: state, helpers: NewGameHelpers(state), payload: payload, clientID: "p1"})
+	if got != MoveUp {
+		t.Fatalf("decide() = %q, want fallback %q", got, MoveUp)
+	}
+	if r.stats.Panics != 1 || r.stats.Fallbacks() != 1 {
+		t.Fatalf("stats = %+v, want one panic", r.stats)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "panic-tick*.json"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("dump files = %v (err %v), want exactly one", files, err)
+	}
+	dump, err := LoadPanicDump(files[0])
+	if err != nil {
+		t.Fatalf("LoadPanicDump() error = %v", err)
+	}
+	if !strings.Contains(dump.Panic, "nil pointer") || !strings.Contains(dump.Stack, "GetNextMove") {
+		t.Fatalf("dump = %+v, want nil pointer panic with stack", dump)
+	}
+	restored, err := dump.State()
+	if err != nil {
+		t.Fatalf("dump.State() error = %v", err)
+	}
+	if restored.Me == nil || restored.Me.ID != "p1" || len(restored.Bombs) != 1 {
+		t.Fatalf("restored state = %+v, want original state", restored)
+	}
+}
+
+func TestDecide_RecoversPanicWithDeadline(t *testing.T) {
+	t.Parallel()
+
+	r := &runner{bot: panickingBot{}, cfg: newConfig([]Option{
+		WithMoveDeadline(time.Second),
+		WithFallback(func(*GameState, *GameHelpers) Action { return MoveRight }),
+	})}
+	state := openFieldState()
+	if got := r.decide(context.Background(), turn{state: state, helpers: NewGameHelpers(state)}); got != MoveRight {
+		t.Fatalf("decide() = %q, want fallback %q", got, MoveRight)
+	}
+	if r.stats.Panics != 1 || r.stats.Late != 0 {
+		t.Fatalf("stats = %+v, want one panic and no late move", r.stats)
+	}
+}
+
+func TestDecideAnytime_RecoversPanic(t *testing.T) {
+	t.Parallel()
+
+	r := &runner{bot: panickingSearchBot{}, cfg: newConfig([]Option{
+		WithFallback(func(*GameState, *GameHelpers) Action { return MoveLeft }),
+	})}
+	state := openFieldState()
+	if got := r.decide(context.Background(), turn{state: state, helpers: NewGameHelpers(state)}); got != MoveLeft {
+		t.Fatalf("decide() = %q, want fallback %q", got, MoveLeft)
+	}
+	if r.stats.Panics != 1 {
+		t.Fatalf("stats.Panics = %d, want 1", r.stats.Panics)
+	}
+}
+
+func TestLoadPanicDump_MissingFile(t *testing.T) {
+	t.Parallel()
+
+	if _, err := LoadPanicDump(filepath.Join(t.TempDir(), "missing.json")); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("LoadPanicDump() error = %v, want not-exist error", err)
+	}
+}
+
+func TestSummarizeState(t *testing.T) {
+	t.Parallel()
+
+	if got := summarizeState(nil); got != "no state" {
+		t.Fatalf("summarizeState(nil) = %q", got)
+	}
+	got := summarizeState(openFieldState())
+	if !strings.Contains(got, "me=me at (2,2)") || !strings.Contains(got, "bombs=1") {
+		t.Fatalf("summarizeState() = %q, want player position and bomb count", got)
+	}
+}
@@ -0,0 +1,105 @@
+package bombahead
+
+// unreachable marks cells of a DistanceMap that cannot be reached from its start
+const unreachable = -1
+
+// pathTree is the result of one BFS from a start position
+type pathTree struct {
+	dist Grid[int]
+	// prev holds the index of the cell each cell was reached from, -1 for the start and unreached cells
+	prev Grid[int]
+}
+
+// DistanceMap returns the number of moves from start to every cell, or -1 for cells that cannot be reached
+// Moves follow GetAdjacentWalkablePositions, while start itself may be blocked, e.g. by the bomb the bot stands on.
+// The search runs once per start and GameHelpers, and every call returns its own copy of the result
+func (h *GameHelpers) DistanceMap(start Position) Grid[int] {
+	dist := h.pathTree(start).dist
+	dist.Cells = append([]int(nil), dist.Cells...)
+	return dist
+}
+
+// ShortestPath returns the cells of a shortest walkable path from start to target, both included
+// It is nil if target cannot be reached, including when start or target lies outside the field,
+// and just start if start is the target
+func (h *GameHelpers) ShortestPath(start, target Position) []Position {
+	tree := h.pathTree(start)
+	idx, ok := tree.dist.Index(target)
+	if !ok || tree.dist.Cells[idx] == unreachable {
+		return nil
+	}
+
+	path := make([]Position, tree.dist.Cells[idx]+1)
+	for i := len(path) - 1; i >= 0; i-- {
+		path[i] = tree.dist.Pos(idx)
+		idx = tree.prev.Cells[idx]
+	}
+	return path
+}
+
+// NextActionsTowardsAny returns the moves from start to the nearest reachable of targets, and that target
+// Ties go to the target listed first. ok is false if no target can be reached; the moves are empty if
+// start is one of the targets. All targets share the cached search of DistanceMap
+func (h *GameHelpers) NextActionsTowardsAny(start Position, targets []Position) (actions []Action, target Position, ok bool) {
+	dist := h.pathTree(start).dist
+	best := unreachable
+	for _, t := range targets {
+		if d, in := dist.Index(t); in && dist.Cells[d] != unreachable && (best == unreachable || dist.Cells[d] < best) {
+			best = dist.Cells[d]
+			target = t
+		}
+	}
+	if best == unreachable {
+		return nil, Position{}, false
+	}
+
+	path := h.ShortestPath(start, target)
+	actions = make([]Action, 0, len(path)-1)
+	for i := 1; i < len(path); i++ {
+		actions = append(actions, actionFromStep(path[i-1], path[i]))
+	}
+	return actions, target, true
+}
+
+// pathTree returns the cached search from start, the result is shared and must not be modified
+func (h *GameHelpers) pathTree(start Position) *pathTree {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if tree, ok := h.paths[start]; ok {
+		return tree
+	}
+
+	var width, height int
+	if h.State != nil {
+		width, height = h.State.Field.Width, h.State.Field.Height
+	}
+	tree := &pathTree{dist: NewGrid[int](width, height), prev: NewGrid[int](width, height)}
+	tree.dist.Fill(unreachable)
+	tree.prev.Fill(-1)
+
+	if startIdx, ok := tree.dist.Index(start); ok {
+		tree.dist.Cells[startIdx] = 0
+		queue := []Position{start}
+		var neighbours [4]Position
+		for head := 0; head < len(queue); head++ {
+			cur := queue[head]
+			curIdx, _ := tree.dist.Index(cur)
+
+			for _, next := range h.appendAdjacentWalkable(neighbours[:0], cur) {
+				nextIdx, _ := tree.dist.Index(next)
+				if tree.dist.Cells[nextIdx] != unreachable {
+					continue
+				}
+				tree.dist.Cells[nextIdx] = tree.dist.Cells[curIdx] + 1
+				tree.prev.Cells[nextIdx] = curIdx
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	if h.paths == nil {
+		h.paths = make(map[Position]*pathTree)
+	}
+	h.paths[start] = tree
+	return tree
+}
@@ -0,0 +1,143 @@
+package bombahead
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestDistanceMap(t *testing.T) {
+	t.Parallel()
+
+	state := MustParseASCII(`
+		a..#.
+		.#.#.
+		...#.
+	`)
+	h := NewGameHelpers(state)
+	dist := h.DistanceMap(state.Me.Pos)
+
+	want := []int{
+		0, 1, 2, -1, -1,
+		1, -1, 3, -1, -1,
+		2, 3, 4, -1, -1,
+	}
+	if !reflect.DeepEqual(dist.Cells, want) {
+		t.Fatalf("DistanceMap().Cells = %v, want %v", dist.Cells, want)
+	}
+	dist.Cells[2] = 99
+	if again := h.DistanceMap(state.Me.Pos); !reflect.DeepEqual(again.Cells, want) {
+		t.Fatalf("DistanceMap() after changing an earlier result = %v, want %v", again.Cells, want)
+	}
+	if path := h.ShortestPath(state.Me.Pos, Position{X: 2, Y: 0}); len(path) != 3 {
+		t.Fatalf("ShortestPath() after changing a DistanceMap = %v, want 3 cells", path)
+	}
+}
+
+func TestShortestPath(t *testing.T) {
+	t.Parallel()
+
+	state := MustParseASCII(`
+		A.+..
+		.##..
+		.....
+	`)
+	h := NewGameHelpers(state)
+
+	got := h.ShortestPath(Position{X: 0, Y: 0}, Position{X: 4, Y: 0})
+	want := []Position{{X: 0, Y: 0}, {X: 0, Y: 1}, {X: 0, Y: 2}, {X: 1, Y: 2}, {X: 2, Y: 2}, {X: 3, Y: 2}, {X: 3, Y: 1}, {X: 3, Y: 0}, {X: 4, Y: 0}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("ShortestPath() = %v, want %v", got, want)
+	}
+
+	if got := h.ShortestPath(Position{X: 0, Y: 0}, Position{X: 0, Y: 0}); !reflect.DeepEqual(got, []Position{{X: 0, Y: 0}}) {
+		t.Fatalf("ShortestPath(start==target) = %v, want just start", got)
+	}
+	if got := h.ShortestPath(Position{X: 0, Y: 0}, Position{X: 2, Y: 0}); got != nil {
+		t.Fatalf("ShortestPath(box) = %v, want nil", got)
+	}
+	if got := h.ShortestPath(Position{X: -1, Y: 0}, Position{X: -1, Y: 0}); got != nil {
+		t.Fatalf("ShortestPath(outside start==target) = %v, want nil", got)
+	}
+	if got := h.ShortestPath(Position{X: 0, Y: 0}, Position{X: 5, Y: 0}); got != nil {
+		t.Fatalf("ShortestPath(outside target) = %v, want nil", got)
+	}
+}
+
+func TestNextActionsTowardsAny(t *testing.T) {
+	t.Parallel()
+
+	state := MustParseASCII(`
+		A....
+		.###.
+		.....
+	`)
+	h := NewGameHelpers(state)
+	start := state.Me.Pos
+
+	tests := []struct {
+		name    string
+		targets []Position
+		actions []Action
+		target  Position
+		ok      bool
+	}{
+		{
+			name:    "nearest wins",
+			targets: []Position{{X: 4, Y: 2}, {X: 0, Y: 2}},
+			actions: []Action{MoveDown, MoveDown},
+			target:  Position{X: 0, Y: 2},
+			ok:      true,
+		},
+		{
+			name:    "tie goes to the first target",
+			targets: []Position{{X: 2, Y: 0}, {X: 1, Y: 2}, {X: 0, Y: 2}},
+			actions: []Action{MoveRight, MoveRight},
+			target:  Position{X: 2, Y: 0},
+			ok:      true,
+		},
+		{
+			name:    "start is a target",
+			targets: []Position{{X: 4, Y: 0}, start},
+			actions: []Action{},
+			target:  start,
+			ok:      true,
+		},
+		{
+			name:    "unreachable and out of bounds",
+			targets: []Position{{X: 2, Y: 1}, {X: 9, Y: 9}},
+		},
+	}
+	for _, tt := range tests {
+		actions, target, ok := h.NextActionsTowardsAny(start, tt.targets)
+		if ok != tt.ok || target != tt.target || !reflect.DeepEqual(actions, tt.actions) {
+			t.Fatalf("%s: NextActionsTowardsAny() = %v, %v, %v, want %v, %v, %v", tt.name, actions, target, ok, tt.actions, tt.target, tt.ok)
+		}
+	}
+}
+
+func TestGameHelpers_ConcurrentCaches(t *testing.T) {
+	t.Parallel()
+
+	state := MustParseASCII(`
+		A....
+		.#.#.
+		..2..
+	`)
+	h := NewGameHelpers(state)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(x int) {
+			defer wg.Done()
+			h.DistanceMap(Position{X: x % 5, Y: 0})
+			h.NextActionsTowardsAny(Position{X: 0, Y: 0}, []Position{{X: 4, Y: 2}})
+			h.DangerMap()
+		}(i)
+	}
+	wg.Wait()
+
+	if len(h.paths) != 5 {
+		t.Fatalf("len(paths) = %d, want one search per start", len(h.paths))
+	}
+}
@@ -0,0 +1,226 @@
+// Package picture draws game states as images using only the standard library
+//
+// Frame draws one state, WritePNG encodes it and WriteGIF animates a sequence of states,
+// e.g. the states of a recorded game, for sharing in bug reports and code review.
+package picture
+
+import (
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"strconv"
+	"time"
+
+	bombahead "github.com/N3moAhead/bombahead-go"
+)
+
+// Options configures the drawing
+type Options struct {
+	// CellSize is the width and height of a cell in pixels; it defaults to 24
+	CellSize int
+	// HideDanger skips the overlay of cells GameHelpers.DangerPositions predicts to be hit
+	HideDanger bool
+}
+
+func (o Options) cellSize() int {
+	if o.CellSize < 8 {
+		return 24
+	}
+	return o.CellSize
+}
+
+var (
+	colorBackground = color.RGBA{R: 30, G: 30, B: 36, A: 255}
+	colorText       = color.RGBA{R: 235, G: 235, B: 235, A: 255}
+	colorAir        = color.RGBA{R: 226, G: 222, B: 208, A: 255}
+	colorWall       = color.RGBA{R: 80, G: 80, B: 92, A: 255}
+	colorBox        = color.RGBA{R: 176, G: 124, B: 64, A: 255}
+	colorExplosion  = color.RGBA{R: 240, G: 88, B: 32, A: 255}
+	colorDanger     = color.NRGBA{R: 230, G: 90, B: 0, A: 110}
+	colorBomb       = color.RGBA{R: 20, G: 20, B: 20, A: 255}
+	colorDead       = color.RGBA{R: 120, G: 120, B: 120, A: 255}
+)
+
+// playerColors are used by player index, repeating for more players
+var playerColors = []color.RGBA{
+	{R: 40, G: 160, B: 70, A: 255},
+	{R: 40, G: 110, B: 210, A: 255},
+	{R: 200, G: 50, B: 160, A: 255},
+	{R: 220, G: 180, B: 20, A: 255},
+}
+
+const (
+	textScale  = 2
+	lineHeight = (glyphHeight + 2) * textScale
+	margin     = 4
+)
+
+// Frame draws state: a tick header, the board and a legend with every player
+// Players are drawn as circles lettered 'A' + their index in Players, like GameState.Render
+func Frame(state *bombahead.GameState, opts Options) *image.RGBA {
+	cs := opts.cellSize()
+	boardW, boardH := state.Field.Width*cs, state.Field.Height*cs
+	legend := make([]string, len(state.Players))
+	width := boardW
+	for i, p := range state.Players {
+		legend[i] = label(i) + " " + p.ID + " HP " + strconv.Itoa(p.Health) + " SCORE " + strconv.Itoa(p.Score)
+		if state.Me != nil && state.Me.ID == p.ID {
+			legend[i] += " ME"
+		}
+		width = max(width, lineHeight+textWidth(legend[i], textScale))
+	}
+	width += 2 * margin
+	height := margin + lineHeight + boardH + margin + len(state.Players)*lineHeight + margin
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	fill(img, img.Bounds(), colorBackground)
+
+	header := "TICK " + strconv.Itoa(state.CurrentTick)
+	if state.MaxTicks > 0 {
+		header += "/" + strconv.Itoa(state.MaxTicks)
+	}
+	drawText(img, image.Pt(margin, margin), header, textScale, colorText)
+
+	origin := image.Pt(margin, margin+lineHeight)
+	cellRect := func(p bombahead.Position) image.Rectangle {
+		min := origin.Add(image.Pt(p.X*cs, p.Y*cs))
+		return image.Rectangle{Min: min, Max: min.Add(image.Pt(cs, cs))}
+	}
+
+	for y := 0; y < state.Field.Height; y++ {
+		for x := 0; x < state.Field.Width; x++ {
+			pos := bombahead.Position{X: x, Y: y}
+			c := colorAir
+			switch state.Field.CellAt(pos) {
+			case bombahead.Wall:
+				c = colorWall
+			case bombahead.Box:
+				c = colorBox
+			}
+			fill(img, cellRect(pos), c)
+		}
+	}
+
+	inBoard := func(p bombahead.Position) bool {
+		return p.X >= 0 && p.X < state.Field.Width && p.Y >= 0 && p.Y < state.Field.Height
+	}
+	if !opts.HideDanger {
+		for _, p := range bombahead.NewGameHelpers(state).DangerPositions() {
+			if inBoard(p) {
+				draw.Draw(img, cellRect(p), image.NewUniform(colorDanger), image.Point{}, draw.Over)
+			}
+		}
+	}
+	for _, p := range state.Explosions {
+		if inBoard(p) {
+			fill(img, cellRect(p).Inset(cs/8), colorExplosion)
+		}
+	}
+
+	digitScale := max(1, cs/12)
+	for _, b := range state.Bombs {
+		if !inBoard(b.Pos) {
+			continue
+		}
+		r := cellRect(b.Pos)
+		center := r.Min.Add(image.Pt(cs/2, cs/2))
+		circle(img, center, cs*3/8, colorBomb)
+		fuse := strconv.Itoa(b.Fuse)
+		drawText(img, center.Sub(image.Pt(textWidth(fuse, digitScale)/2, glyphHeight*digitScale/2)), fuse, digitScale, colorText)
+	}
+
+	for i, p := range state.Players {
+		c := playerColor(i, p)
+		if inBoard(p.Pos) {
+			r := cellRect(p.Pos)
+			center := r.Min.Add(image.Pt(cs/2, cs/2))
+			radius := cs * 5 / 16
+			if hasBomb(state, p.Pos) {
+				// Keep the bomb visible as a ring around the player
+				radius = cs / 4
+			}
+			circle(img, center, radius, c)
+			drawText(img, center.Sub(image.Pt(textWidth("A", digitScale)/2, glyphHeight*digitScale/2)), label(i), digitScale, colorText)
+		}
+
+		y := origin.Y + boardH + margin + i*lineHeight
+		fill(img, image.Rect(margin, y, margin+glyphHeight*textScale, y+glyphHeight*textScale), c)
+		drawText(img, image.Pt(margin+lineHeight, y), legend[i], textScale, colorText)
+	}
+	return img
+}
+
+// WritePNG draws state and encodes it as PNG
+func WritePNG(w io.Writer, state *bombahead.GameState, opts Options) error {
+	return png.Encode(w, Frame(state, opts))
+}
+
+// Animate draws every state as one frame of a looping GIF, delay apart
+// Frames are reduced to the Plan 9 palette
+func Animate(states []*bombahead.GameState, delay time.Duration, opts Options) *gif.GIF {
+	anim := &gif.GIF{}
+	hundredths := max(1, int(delay/(10*time.Millisecond)))
+	for _, s := range states {
+		frame := Frame(s, opts)
+		paletted := image.NewPaletted(frame.Bounds(), palette.Plan9)
+		draw.Draw(paletted, paletted.Bounds(), frame, image.Point{}, draw.Src)
+		anim.Image = append(anim.Image, paletted)
+		anim.Delay = append(anim.Delay, hundredths)
+	}
+	return anim
+}
+
+// WriteGIF animates states and encodes them as GIF
+// Frames of different size, e.g. from games on different boards, are drawn at the top left of the first frame's size
+func WriteGIF(w io.Writer, states []*bombahead.GameState, delay time.Duration, opts Options) error {
+	anim := Animate(states, delay, opts)
+	if len(anim.Image) > 0 {
+		anim.Config = image.Config{ColorModel: anim.Image[0].Palette, Width: anim.Image[0].Rect.Dx(), Height: anim.Image[0].Rect.Dy()}
+		for _, f := range anim.Image {
+			anim.Config.Width = max(anim.Config.Width, f.Rect.Dx())
+			anim.Config.Height = max(anim.Config.Height, f.Rect.Dy())
+		}
+	}
+	return gif.EncodeAll(w, anim)
+}
+
+func playerColor(i int, p bombahead.Player) color.RGBA {
+	if p.Health <= 0 {
+		return colorDead
+	}
+	return playerColors[i%len(playerColors)]
+}
+
+func label(i int) string {
+	if i >= 26 {
+		return "?"
+	}
+	return string(rune('A' + i))
+}
+
+func hasBomb(state *bombahead.GameState, pos bombahead.Position) bool {
+	for _, b := range state.Bombs {
+		if b.Pos == pos {
+			return true
+		}
+	}
+	return false
+}
+
+func fill(img *image.RGBA, r image.Rectangle, c color.Color) {
+	draw.Draw(img, r, image.NewUniform(c), image.Point{}, draw.Src)
+}
+
+func circle(img *image.RGBA, center image.Point, radius int, c color.RGBA) {
+	for y := -radius; y <= radius; y++ {
+		for x := -radius; x <= radius; x++ {
+			if x*x+y*y <= radius*radius {
+				img.SetRGBA(center.X+x, center.Y+y, c)
+			}
+		}
+	}
+}
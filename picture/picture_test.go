@@ -0,0 +1,112 @@
+package picture
+
+import (
+	"bytes"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"testing"
+	"time"
+
+	bombahead "github.com/N3moAhead/bombahead-go"
+)
+
+func testState() *bombahead.GameState {
+	return bombahead.MustParseASCII(`
+		tick 4
+		#####
+		#A..#
+		#.#.#
+		#..1#
+		#*..B
+		A alice hp=3 score=2 me
+		B bob hp=0 score=0
+	`)
+}
+
+// cellCenter returns the color in the middle of the cell at x,y for the default cell size
+func cellCenter(img interface{ At(x, y int) color.Color }, x, y int) color.RGBA {
+	cs := Options{}.cellSize()
+	c := img.At(margin+x*cs+cs/2, margin+lineHeight+y*cs+2)
+	r, g, b, a := c.RGBA()
+	return color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: uint8(a >> 8)}
+}
+
+func TestFrame(t *testing.T) {
+	t.Parallel()
+
+	img := Frame(testState(), Options{})
+
+	cs := Options{}.cellSize()
+	if img.Bounds().Dx() < 5*cs+2*margin || img.Bounds().Dy() != margin+lineHeight+5*cs+margin+2*lineHeight+margin {
+		t.Fatalf("Frame() bounds = %v, want board plus header and two legend lines", img.Bounds())
+	}
+
+	tests := []struct {
+		name string
+		x, y int
+		want color.RGBA
+	}{
+		{"wall", 0, 0, colorWall},
+		{"air", 2, 1, colorAir},
+		{"player marker top edge is air", 1, 1, colorAir},
+	}
+	for _, tt := range tests {
+		if got := cellCenter(img, tt.x, tt.y); got != tt.want {
+			t.Fatalf("%s at %d,%d = %v, want %v", tt.name, tt.x, tt.y, got, tt.want)
+		}
+	}
+
+	// The bomb at 3,3 with fuse 1 endangers its lane; the cell above it is darkened
+	if got := cellCenter(img, 3, 2); got == colorAir || got == colorWall {
+		t.Fatalf("danger at 3,2 = %v, want overlay", got)
+	}
+	if got := cellCenter(Frame(testState(), Options{HideDanger: true}), 3, 2); got != colorAir {
+		t.Fatalf("HideDanger at 3,2 = %v, want air", got)
+	}
+
+	center := margin + lineHeight + cs + cs/2
+	if got := img.RGBAAt(margin+cs+cs/2-cs/4, center); got != playerColors[0] {
+		t.Fatalf("player A marker = %v, want %v", got, playerColors[0])
+	}
+}
+
+func TestWritePNGAndGIF(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	if err := WritePNG(&buf, testState(), Options{CellSize: 16}); err != nil {
+		t.Fatalf("WritePNG() error = %v", err)
+	}
+	if _, err := png.Decode(&buf); err != nil {
+		t.Fatalf("png.Decode() error = %v", err)
+	}
+
+	states := []*bombahead.GameState{testState(), testState(), testState()}
+	buf.Reset()
+	if err := WriteGIF(&buf, states, 150*time.Millisecond, Options{}); err != nil {
+		t.Fatalf("WriteGIF() error = %v", err)
+	}
+	anim, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatalf("gif.DecodeAll() error = %v", err)
+	}
+	if len(anim.Image) != 3 || anim.Delay[0] != 15 {
+		t.Fatalf("GIF has %d frames with delay %v, want 3 frames of 15", len(anim.Image), anim.Delay)
+	}
+}
+
+func TestDrawText(t *testing.T) {
+	t.Parallel()
+
+	if got := textWidth("ab-1", 2); got != (4*4-1)*2 {
+		t.Fatalf("textWidth() = %d, want %d", got, (4*4-1)*2)
+	}
+	for r, g := range glyphs {
+		for _, row := range g {
+			if len(row) != glyphWidth {
+				t.Fatalf("glyph %q row %q has width %d, want %d", r, row, len(row), glyphWidth)
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package bombahead
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+)
+
+// ReconnectPolicy controls automatic reconnection after the connection to the server drops
+// Zero fields are replaced by the values of DefaultReconnectPolicy, except MaxAttempts
+// where zero means retrying forever
+type ReconnectPolicy struct {
+	// MaxAttempts is the number of consecutive failed attempts before giving up
+	MaxAttempts int
+	// InitialBackoff is the delay before the first attempt
+	InitialBackoff time.Duration
+	// MaxBackoff caps the delay between two attempts
+	MaxBackoff time.Duration
+	// Multiplier is applied to the delay after every failed attempt
+	Multiplier float64
+	// Jitter randomizes each delay by up to this fraction in both directions
+	Jitter float64
+}
+
+// DefaultReconnectPolicy returns the policy used for zero fields of a ReconnectPolicy
+func DefaultReconnectPolicy() ReconnectPolicy {
+	return ReconnectPolicy{
+		MaxAttempts:    10,
+		InitialBackoff: 250 * time.Millisecond,
+		MaxBackoff:     10 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+	}
+}
+
+// WithReconnect enables automatic reconnection using policy
+// The initial dial is retried as well, so a bot may be started before the server
+func WithReconnect(policy ReconnectPolicy) Option {
+	return func(c *config) {
+		def := DefaultReconnectPolicy()
+		if policy.InitialBackoff <= 0 {
+			policy.InitialBackoff = def.InitialBackoff
+		}
+		if policy.MaxBackoff <= 0 {
+			policy.MaxBackoff = def.MaxBackoff
+		}
+		if policy.Multiplier < 1 {
+			policy.Multiplier = def.Multiplier
+		}
+		if policy.Jitter < 0 || policy.Jitter > 1 {
+			policy.Jitter = def.Jitter
+		}
+		c.reconnect = &policy
+	}
+}
+
+// backoff returns the delay before the given attempt (starting at 1)
+// rnd must be in [0, 1) and selects the jitter
+func (p ReconnectPolicy) backoff(attempt int, rnd float64) time.Duration {
+	delay := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(attempt-1))
+	if delay > float64(p.MaxBackoff) {
+		delay = float64(p.MaxBackoff)
+	}
+	delay += delay * p.Jitter * (2*rnd - 1)
+	if delay < 0 {
+		delay = 0
+	}
+	return time.Duration(delay)
+}
+
+// ReconnectEventKind describes what happened to the connection
+type ReconnectEventKind string
+
+const (
+	// ReconnectDisconnected is reported when an established connection drops
+	ReconnectDisconnected ReconnectEventKind = "disconnected"
+	// ReconnectAttempting is reported before waiting for the next dial attempt
+	ReconnectAttempting ReconnectEventKind = "attempting"
+	// ReconnectSucceeded is reported when a new connection has been established
+	ReconnectSucceeded ReconnectEventKind = "succeeded"
+	// ReconnectGaveUp is reported when MaxAttempts has been exhausted
+	ReconnectGaveUp ReconnectEventKind = "gave_up"
+)
+
+// ReconnectEvent is passed to bots implementing ReconnectListener
+type ReconnectEvent struct {
+	Kind ReconnectEventKind
+	// Attempt is the number of the current attempt, or zero for ReconnectDisconnected
+	Attempt int
+	// Delay is the backoff before the attempt, set for ReconnectAttempting
+	Delay time.Duration
+	// Err is the error that caused the disconnect or the last failed attempt
+	Err error
+	// ClientID is the welcome ID the client tries to resume
+	ClientID string
+}
+
+// ReconnectListener can be implemented by a Bot to observe reconnects
+type ReconnectListener interface {
+	OnReconnect(event ReconnectEvent)
+}
+
+// retryable reports whether err may be resolved by dialing again
+func (r *runner) retryable(ctx context.Context, err error) bool {
+	if r.cfg.reconnect == nil || ctx.Err() != nil {
+		return false
+	}
+	return errors.Is(err, ErrDial) || errors.Is(err, ErrServerClosed) || errors.Is(err, ErrConnectionLost)
+}
+
+func (r *runner) notifyReconnect(event ReconnectEvent) {
+	if l, ok := r.bot.(ReconnectListener); ok {
+		l.OnReconnect(event)
+	}
+}
+
+// sleepContext waits for d or until ctx is cancelled
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,160 @@
+package bombahead
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestReconnectPolicyBackoff(t *testing.T) {
+	t.Parallel()
+
+	policy := ReconnectPolicy{
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+		Jitter:         0.5,
+	}
+
+	tests := []struct {
+		attempt int
+		rnd     float64
+		want    time.Duration
+	}{
+		{attempt: 1, rnd: 0.5, want: 100 * time.Millisecond},
+		{attempt: 3, rnd: 0.5, want: 400 * time.Millisecond},
+		{attempt: 10, rnd: 0.5, want: time.Second},
+		{attempt: 1, rnd: 0, want: 50 * time.Millisecond},
+		{attempt: 2, rnd: 1, want: 300 * time.Millisecond},
+	}
+
+	for _, tc := range tests {
+		if got := policy.backoff(tc.attempt, tc.rnd); got != tc.want {
+			t.Fatalf("backoff(%d, %v) = %s, want %s", tc.attempt, tc.rnd, got, tc.want)
+		}
+	}
+}
+
+func TestWithReconnect_FillsDefaults(t *testing.T) {
+	t.Parallel()
+
+	cfg := newConfig([]Option{WithReconnect(ReconnectPolicy{MaxAttempts: 3})})
+	def := DefaultReconnectPolicy()
+	if cfg.reconnect == nil {
+		t.Fatal("WithReconnect() did not set a policy")
+	}
+	if cfg.reconnect.MaxAttempts != 3 || cfg.reconnect.InitialBackoff != def.InitialBackoff || cfg.reconnect.Multiplier != def.Multiplier {
+		t.Fatalf("policy = %+v, want defaults with MaxAttempts=3", *cfg.reconnect)
+	}
+}
+
+type reconnectRecorder struct {
+	constantBot
+	mu     sync.Mutex
+	events []ReconnectEventKind
+}
+
+func (b *reconnectRecorder) OnReconnect(event ReconnectEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events = append(b.events, event.Kind)
+}
+
+func TestRunContext_ReconnectResumesSession(t *testing.T) {
+	t.Parallel()
+
+	var (
+		mu          sync.Mutex
+		connections int
+	)
+	resumeID := make(chan string, 1)
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		connections++
+		n := connections
+		mu.Unlock()
+
+		if n > 2 {
+			http.Error(w, "go away", http.StatusUnauthorized)
+			return
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade failed: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		ready := readTestMessage(t, conn)
+		if n == 1 {
+			writeTestMessage(t, conn, msgWelcome, welcomePayload{ClientID: "bot-42"})
+			_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "restart"))
+			return
+		}
+
+		var status playerStatusUpdatePayload
+		if err := json.Unmarshal(ready.Payload, &status); err != nil {
+			t.Errorf("unmarshal ready payload: %v", err)
+		}
+		resumeID <- status.ClientID
+		_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	}))
+	defer server.Close()
+
+	bot := &reconnectRecorder{}
+	err := RunContext(context.Background(), bot,
+		WithURL("ws"+strings.TrimPrefix(server.URL, "http")),
+		WithReconnect(ReconnectPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}),
+	)
+	if !errors.Is(err, ErrAuthRejected) {
+		t.Fatalf("RunContext() error = %v, want ErrAuthRejected from third dial", err)
+	}
+
+	if got := <-resumeID; got != "bot-42" {
+		t.Fatalf("resumed client ID = %q, want %q", got, "bot-42")
+	}
+
+	want := []ReconnectEventKind{
+		ReconnectDisconnected, ReconnectAttempting, ReconnectSucceeded,
+		ReconnectDisconnected, ReconnectAttempting,
+	}
+	bot.mu.Lock()
+	defer bot.mu.Unlock()
+	if len(bot.events) != len(want) {
+		t.Fatalf("events = %v, want %v", bot.events, want)
+	}
+	for i := range want {
+		if bot.events[i] != want[i] {
+			t.Fatalf("events = %v, want %v", bot.events, want)
+		}
+	}
+}
+
+func TestRunContext_ReconnectGivesUp(t *testing.T) {
+	t.Parallel()
+
+	bot := &reconnectRecorder{}
+	err := RunContext(context.Background(), bot,
+		WithURL("ws://127.0.0.1:1/ws"),
+		WithReconnect(ReconnectPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}),
+	)
+	if !errors.Is(err, ErrDial) {
+		t.Fatalf("RunContext() error = %v, want ErrDial", err)
+	}
+
+	bot.mu.Lock()
+	defer bot.mu.Unlock()
+	if n := len(bot.events); n == 0 || bot.events[n-1] != ReconnectGaveUp {
+		t.Fatalf("events = %v, want trailing %q", bot.events, ReconnectGaveUp)
+	}
+}
@@ -0,0 +1,260 @@
+package bombahead
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ReplayFormat identifies replay files in their header line
+	ReplayFormat = "bombahead-replay"
+	// ReplayVersion is the replay file version written by Recorder
+	ReplayVersion = 1
+)
+
+// ErrReplayFormat is returned when a file is not a readable replay
+var ErrReplayFormat = errors.New("invalid replay file")
+
+// ReplayHeader is the first line of a replay file
+type ReplayHeader struct {
+	Format  string    `json:"format"`
+	Version int       `json:"version"`
+	Created time.Time `json:"created"`
+}
+
+// ReplayDirection tells whether a message was received or sent by the bot
+type ReplayDirection string
+
+const (
+	// ReplayIn marks a message received from the server
+	ReplayIn ReplayDirection = "in"
+	// ReplayOut marks a message sent by the bot
+	ReplayOut ReplayDirection = "out"
+)
+
+// ReplayEntry is one recorded message
+type ReplayEntry struct {
+	Time      time.Time       `json:"t"`
+	Direction ReplayDirection `json:"dir"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+}
+
+// Recorder writes the messages of the client loop to a replay file
+// Every received message is recorded, including welcome with the client ID, and every sent classic_input
+// Other sent messages are skipped, so the auth token never ends up in a replay
+type Recorder struct {
+	mu      sync.Mutex
+	w       *bufio.Writer
+	closers []io.Closer
+	err     error
+	closed  bool
+	now     func() time.Time
+}
+
+// NewRecorder writes a replay to w
+// Close flushes the recording but does not close w
+func NewRecorder(w io.Writer) (*Recorder, error) {
+	rec := &Recorder{w: bufio.NewWriter(w), now: time.Now}
+	header := ReplayHeader{Format: ReplayFormat, Version: ReplayVersion, Created: rec.now()}
+	if err := rec.writeLine(header); err != nil {
+		return nil, fmt.Errorf("write replay header: %w", err)
+	}
+	return rec, nil
+}
+
+// CreateRecorder writes a replay to the file at path, gzip compressed if path ends in .gz
+func CreateRecorder(path string) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("create replay: %w", err)
+	}
+
+	var w io.Writer = f
+	closers := []io.Closer{f}
+	if strings.HasSuffix(path, ".gz") {
+		gz := gzip.NewWriter(f)
+		w = gz
+		closers = []io.Closer{gz, f}
+	}
+
+	rec, err := NewRecorder(w)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	rec.closers = closers
+	return rec, nil
+}
+
+// WithRecorder records the game to rec
+// The recorder stays open after RunContext returns; the caller closes it
+func WithRecorder(rec *Recorder) Option {
+	return func(c *config) {
+		c.recorder = rec
+	}
+}
+
+// Close flushes the recording and closes the file opened by CreateRecorder
+// It returns the first error hit while recording; later calls return nil
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	if err := r.w.Flush(); err != nil && r.err == nil {
+		r.err = err
+	}
+	for _, c := range r.closers {
+		if err := c.Close(); err != nil && r.err == nil {
+			r.err = err
+		}
+	}
+	r.closers = nil
+	return r.err
+}
+
+func (r *Recorder) record(dir ReplayDirection, msgType string, payload json.RawMessage) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil || r.closed {
+		return
+	}
+	entry := ReplayEntry{Time: r.now(), Direction: dir, Type: msgType, Payload: payload}
+	if err := r.writeLine(entry); err != nil {
+		r.err = err
+		log.Printf("Recording stopped: %v", err)
+	}
+}
+
+func (r *Recorder) writeLine(v any) error {
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	_, err = r.w.Write(line)
+	return err
+}
+
+// recordingTransport records the traffic of the Transport it wraps
+type recordingTransport struct {
+	Transport
+	rec *Recorder
+}
+
+func (t recordingTransport) ReadMessage() (*Message, error) {
+	msg, err := t.Transport.ReadMessage()
+	if err == nil {
+		t.rec.record(ReplayIn, msg.Type, msg.Payload)
+	}
+	return msg, err
+}
+
+func (t recordingTransport) Send(msgType string, payload any) error {
+	err := t.Transport.Send(msgType, payload)
+	if err == nil && msgType == msgClassicInput {
+		if data, merr := json.Marshal(payload); merr == nil {
+			t.rec.record(ReplayOut, msgType, data)
+		}
+	}
+	return err
+}
+
+// Recording is a replay file read back into memory
+type Recording struct {
+	Header ReplayHeader
+	// ClientID is the ID from the first recorded welcome message
+	ClientID string
+	Entries  []ReplayEntry
+}
+
+// ReadReplay reads a replay written by Recorder, gzip compressed or not
+func ReadReplay(r io.Reader) (*Recording, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrReplayFormat, err)
+		}
+		defer gz.Close()
+		br = bufio.NewReader(gz)
+	}
+
+	dec := json.NewDecoder(br)
+	var rec Recording
+	if err := dec.Decode(&rec.Header); err != nil {
+		return nil, fmt.Errorf("%w: header: %w", ErrReplayFormat, err)
+	}
+	if rec.Header.Format != ReplayFormat {
+		return nil, fmt.Errorf("%w: format %q", ErrReplayFormat, rec.Header.Format)
+	}
+	if rec.Header.Version < 1 || rec.Header.Version > ReplayVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrReplayFormat, rec.Header.Version)
+	}
+
+	for {
+		var entry ReplayEntry
+		err := dec.Decode(&entry)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: entry %d: %w", ErrReplayFormat, len(rec.Entries)+1, err)
+		}
+		if rec.ClientID == "" && entry.Direction == ReplayIn && entry.Type == msgWelcome {
+			var welcome welcomePayload
+			if json.Unmarshal(entry.Payload, &welcome) == nil {
+				rec.ClientID = welcome.ClientID
+			}
+		}
+		rec.Entries = append(rec.Entries, entry)
+	}
+	return &rec, nil
+}
+
+// States parses every recorded classic_state from the perspective of the recorded bot
+// Ticks the server did not send are counted like in the client, restarting at every game_start
+func (r *Recording) States() ([]*GameState, error) {
+	var parser stateParser
+	var states []*GameState
+	for _, e := range r.Entries {
+		if e.Direction != ReplayIn {
+			continue
+		}
+		switch e.Type {
+		case msgGameStart, msgBackToLobby:
+			parser.reset()
+		case msgClassicState:
+			state, _, err := parser.parse(e.Payload, r.ClientID)
+			if err != nil {
+				return nil, fmt.Errorf("state %d: %w", len(states), err)
+			}
+			states = append(states, state)
+		}
+	}
+	return states, nil
+}
+
+// LoadReplay reads the replay file at path
+func LoadReplay(path string) (*Recording, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open replay: %w", err)
+	}
+	defer f.Close()
+	return ReadReplay(f)
+}
@@ -0,0 +1,152 @@
+package bombahead
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// playPipeGame serves one game of the given states over a pipe and records it into path
+func playPipeGame(t *testing.T, path string, bot Bot, states ...map[string]any) {
+	t.Helper()
+
+	rec, err := CreateRecorder(path)
+	if err != nil {
+		t.Fatalf("CreateRecorder() error = %v", err)
+	}
+
+	client, server := NewPipe()
+	go func() {
+		defer server.Close()
+		if _, err := server.ReadMessage(); err != nil {
+			return
+		}
+		_ = server.Send(msgWelcome, welcomePayload{ClientID: "p1"})
+		for _, state := range states {
+			_ = server.Send(msgClassicState, state)
+			if _, err := server.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	if err := RunContext(context.Background(), bot, WithTransport(client), WithRecorder(rec)); !errors.Is(err, ErrServerClosed) {
+		t.Fatalf("RunContext() error = %v, want ErrServerClosed", err)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+}
+
+func pipeState(tick int) map[string]any {
+	return map[string]any{
+		"tick":    tick,
+		"players": []Player{{ID: "p1", Pos: Position{X: 1, Y: 1}}, {ID: "p2", Pos: Position{X: 2, Y: 1}}},
+		"field": map[string]any{"width": 3, "height": 3, "field": []string{
+			"WALL", "WALL", "WALL",
+			"WALL", "AIR", "AIR",
+			"WALL", "AIR", "AIR",
+		}},
+	}
+}
+
+func TestRecorder_RecordsGame(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"game.jsonl", "game.jsonl.gz"} {
+		path := filepath.Join(t.TempDir(), name)
+		playPipeGame(t, path, constantBot{action: MoveDown}, pipeState(1), pipeState(2))
+
+		rec, err := LoadReplay(path)
+		if err != nil {
+			t.Fatalf("LoadReplay(%s) error = %v", name, err)
+		}
+		if rec.Header.Format != ReplayFormat || rec.Header.Version != ReplayVersion {
+			t.Fatalf("header = %+v", rec.Header)
+		}
+		if rec.ClientID != "p1" {
+			t.Fatalf("ClientID = %q, want p1", rec.ClientID)
+		}
+
+		var got []string
+		for _, e := range rec.Entries {
+			got = append(got, string(e.Direction)+" "+e.Type)
+			if e.Time.IsZero() {
+				t.Fatalf("entry %s %s has no time", e.Direction, e.Type)
+			}
+		}
+		want := "in welcome,in classic_state,out classic_input,in classic_state,out classic_input"
+		if strings.Join(got, ",") != want {
+			t.Fatalf("%s entries = %v, want %s", name, got, want)
+		}
+
+		var input classicInputPayload
+		if err := json.Unmarshal(rec.Entries[2].Payload, &input); err != nil || input.Move != MoveDown {
+			t.Fatalf("recorded input = %s (%v), want move down", rec.Entries[2].Payload, err)
+		}
+
+		states, err := rec.States()
+		if err != nil || len(states) != 2 || states[1].CurrentTick != 2 || states[1].Me.ID != "p1" {
+			t.Fatalf("States() = %v, %v, want ticks 1 and 2 as p1", states, err)
+		}
+	}
+}
+
+func TestReadReplay_RejectsInvalidFiles(t *testing.T) {
+	t.Parallel()
+
+	tests := []string{
+		"",
+		"not json",
+		`{"format":"something-else","version":1}`,
+		`{"format":"bombahead-replay","version":99}`,
+		`{"format":"bombahead-replay","version":1}` + "\n{broken",
+	}
+	for _, input := range tests {
+		if _, err := ReadReplay(strings.NewReader(input)); !errors.Is(err, ErrReplayFormat) {
+			t.Fatalf("ReadReplay(%q) error = %v, want ErrReplayFormat", input, err)
+		}
+	}
+}
+
+func TestNewRecorder_WritesHeader(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	rec, err := NewRecorder(&buf)
+	if err != nil {
+		t.Fatalf("NewRecorder() error = %v", err)
+	}
+	rec.record(ReplayIn, msgWelcome, json.RawMessage(`{"clientId":"x"}`))
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	got, err := ReadReplay(&buf)
+	if err != nil {
+		t.Fatalf("ReadReplay() error = %v", err)
+	}
+	if got.ClientID != "x" || len(got.Entries) != 1 {
+		t.Fatalf("ReadReplay() = %+v, want one welcome from x", got)
+	}
+}
+
+func TestRecorder_CloseTwice(t *testing.T) {
+	t.Parallel()
+
+	rec, err := CreateRecorder(filepath.Join(t.TempDir(), "game.jsonl.gz"))
+	if err != nil {
+		t.Fatalf("CreateRecorder() error = %v", err)
+	}
+	if err := rec.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	rec.record(ReplayIn, msgWelcome, json.RawMessage(`{}`))
+	if err := rec.Close(); err != nil {
+		t.Fatalf("second Close() error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,144 @@
+package bombahead
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Divergence is a tick on which the replayed bot chose another action than the recording
+type Divergence struct {
+	Tick     int
+	Recorded Action
+	Replayed Action
+	// State is the state both actions were chosen for
+	State *GameState
+}
+
+// ReplayResult summarizes a replay
+type ReplayResult struct {
+	// States counts the classic_state messages fed to the bot
+	States int
+	// Compared counts the states with a recorded action to compare against
+	Compared    int
+	Divergences []Divergence
+}
+
+// FirstDivergence returns the earliest divergence, or nil if the bot matched the recording
+func (r *ReplayResult) FirstDivergence() *Divergence {
+	if len(r.Divergences) == 0 {
+		return nil
+	}
+	return &r.Divergences[0]
+}
+
+// Err describes the first divergence, or returns nil if the bot matched the recording
+func (r *ReplayResult) Err() error {
+	d := r.FirstDivergence()
+	if d == nil {
+		return nil
+	}
+	return fmt.Errorf("bot diverged on tick %d: recorded %q, replayed %q (%d of %d compared ticks differ)",
+		d.Tick, d.Recorded, d.Replayed, len(r.Divergences), r.Compared)
+}
+
+// Replay re-drives bot with the game recorded at path and compares its actions with the recorded ones
+func Replay(path string, bot Bot, opts ...Option) (*ReplayResult, error) {
+	rec, err := LoadReplay(path)
+	if err != nil {
+		return nil, err
+	}
+	return ReplayRecording(rec, bot, opts...)
+}
+
+// ReplayRecording feeds every received message of rec through the client loop into bot
+// States are parsed and lifecycle listeners called exactly as in a live game, but nothing is sent
+// The bot runs without a move deadline unless WithMoveDeadline is passed
+func ReplayRecording(rec *Recording, bot Bot, opts ...Option) (*ReplayResult, error) {
+	cfg := newConfig(opts)
+	r := &runner{bot: bot, cfg: cfg, myID: rec.ClientID, parser: stateParser{strict: cfg.strict}}
+
+	type step struct {
+		state              *GameState
+		recorded, replayed Action
+		hasRecorded        bool
+	}
+	var steps []*step
+	var current *step
+	out := &capturingTransport{}
+
+	for _, entry := range rec.Entries {
+		switch {
+		case entry.Direction == ReplayOut && entry.Type == msgClassicInput:
+			if current == nil || current.hasRecorded {
+				continue
+			}
+			var input classicInputPayload
+			if err := json.Unmarshal(entry.Payload, &input); err != nil {
+				return nil, fmt.Errorf("%w: recorded input: %w", ErrReplayFormat, err)
+			}
+			current.recorded, current.hasRecorded = input.Move, true
+
+		case entry.Direction == ReplayIn:
+			out.input = nil
+			prev := r.lastState
+			if err := r.handle(context.Background(), out, &Message{Type: entry.Type, Payload: entry.Payload}); err != nil {
+				return nil, err
+			}
+			if entry.Type != msgClassicState {
+				continue
+			}
+			// lastState only changes if the state could be parsed
+			current = &step{}
+			if r.lastState != prev {
+				current.state = r.lastState
+			}
+			if out.input != nil {
+				current.replayed = out.input.Move
+			}
+			steps = append(steps, current)
+		}
+	}
+
+	result := &ReplayResult{States: len(steps)}
+	for _, s := range steps {
+		if !s.hasRecorded {
+			continue
+		}
+		result.Compared++
+		if s.recorded != s.replayed {
+			tick := 0
+			if s.state != nil {
+				tick = s.state.CurrentTick
+			}
+			result.Divergences = append(result.Divergences, Divergence{
+				Tick:     tick,
+				Recorded: s.recorded,
+				Replayed: s.replayed,
+				State:    s.state,
+			})
+		}
+	}
+	return result, nil
+}
+
+// capturingTransport keeps the last classic_input sent during a replay
+type capturingTransport struct {
+	input *classicInputPayload
+}
+
+func (t *capturingTransport) ReadMessage() (*Message, error) {
+	return nil, errors.New("replay transport cannot read")
+}
+
+func (t *capturingTransport) Send(msgType string, payload any) error {
+	if input, ok := payload.(classicInputPayload); ok && msgType == msgClassicInput {
+		t.input = &input
+	}
+	return nil
+}
+
+func (t *capturingTransport) Close() error {
+	return nil
+}
@@ -0,0 +1,72 @@
+package bombahead
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// changedBot moves down like the recorded bot until tick from
+type changedBot struct {
+	from int
+}
+
+func (b changedBot) GetNextMove(state *GameState, _ *GameHelpers) Action {
+	if state.CurrentTick >= b.from {
+		return MoveRight
+	}
+	return MoveDown
+}
+
+func TestReplay(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "game.jsonl")
+	playPipeGame(t, path, constantBot{action: MoveDown}, pipeState(1), pipeState(2), pipeState(3))
+
+	tests := []struct {
+		name      string
+		bot       Bot
+		diverged  int
+		firstTick int
+	}{
+		{name: "same bot", bot: constantBot{action: MoveDown}},
+		{name: "changed from tick 2", bot: changedBot{from: 2}, diverged: 2, firstTick: 2},
+		{name: "changed from tick 4", bot: changedBot{from: 4}},
+	}
+	for _, tt := range tests {
+		result, err := Replay(path, tt.bot)
+		if err != nil {
+			t.Fatalf("%s: Replay() error = %v", tt.name, err)
+		}
+		if result.States != 3 || result.Compared != 3 {
+			t.Fatalf("%s: replayed %d states, compared %d, want 3 and 3", tt.name, result.States, result.Compared)
+		}
+		if len(result.Divergences) != tt.diverged {
+			t.Fatalf("%s: %d divergences, want %d", tt.name, len(result.Divergences), tt.diverged)
+		}
+		first := result.FirstDivergence()
+		if tt.diverged == 0 {
+			if first != nil || result.Err() != nil {
+				t.Fatalf("%s: FirstDivergence() = %+v, Err() = %v, want none", tt.name, first, result.Err())
+			}
+			continue
+		}
+		if first.Tick != tt.firstTick || first.Recorded != MoveDown || first.Replayed != MoveRight {
+			t.Fatalf("%s: FirstDivergence() = %+v, want tick %d down vs right", tt.name, first, tt.firstTick)
+		}
+		if first.State == nil || first.State.Me == nil || first.State.Me.ID != "p1" {
+			t.Fatalf("%s: divergence state = %+v, want the view of p1", tt.name, first.State)
+		}
+		if result.Err() == nil {
+			t.Fatalf("%s: Err() = nil, want divergence error", tt.name)
+		}
+	}
+}
+
+func TestReplay_MissingFile(t *testing.T) {
+	t.Parallel()
+
+	if _, err := Replay(filepath.Join(t.TempDir(), "missing.jsonl"), constantBot{}); err == nil {
+		t.Fatalf("Replay() error = nil, want error")
+	}
+}
@@ -0,0 +1,111 @@
+package bombahead
+
+// SafePathTo returns the shortest action sequence from start to target that never stands
+// in a blast at the moment it goes off, using the timeline of DangerMap
+// The path may wait in place (DoNothing) for a blast to pass, and may cross cells of bombs
+// that have already exploded. Boxes are treated as permanent obstacles.
+// ok is false if target cannot be reached safely; the path is empty if start is the target
+func (h *GameHelpers) SafePathTo(start, target Position) (actions []Action, ok bool) {
+	return h.safePath(start, 0, func(pos Position, _ int) bool { return pos == target })
+}
+
+// timedStep is a node of the space-time search
+type timedStep struct {
+	pos  Position
+	tick int
+}
+
+// safePath searches positions over time, standing on start at startTick, until goal accepts a position at a tick
+// Ticks beyond the danger horizon are folded into the horizon, as nothing changes after it
+func (h *GameHelpers) safePath(start Position, startTick int, goal func(pos Position, tick int) bool) ([]Action, bool) {
+	if h.State == nil || !h.inBounds(start) {
+		return nil, false
+	}
+	if goal(start, startTick) {
+		return []Action{}, true
+	}
+
+	danger := h.DangerMap()
+	horizon := max(danger.horizon(), startTick)
+	width, height := h.State.Field.Width, h.State.Field.Height
+	index := func(s timedStep) int { return (s.tick*height+s.pos.Y)*width + s.pos.X }
+
+	type link struct {
+		prev   int
+		action Action
+	}
+	// visited holds the link that reached each node first; prev is -2 for unvisited nodes
+	visited := make([]link, (horizon+1)*width*height)
+	for i := range visited {
+		visited[i].prev = -2
+	}
+	first := timedStep{pos: start, tick: startTick}
+	visited[index(first)] = link{prev: -1}
+	queue := []timedStep{first}
+
+	moves := []struct {
+		action Action
+		delta  Position
+	}{
+		{MoveUp, Position{X: 0, Y: -1}},
+		{MoveRight, Position{X: 1, Y: 0}},
+		{MoveDown, Position{X: 0, Y: 1}},
+		{MoveLeft, Position{X: -1, Y: 0}},
+		{DoNothing, Position{}},
+	}
+
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		tick := cur.tick + 1
+
+		for _, m := range moves {
+			pos := Position{X: cur.pos.X + m.delta.X, Y: cur.pos.Y + m.delta.Y}
+			if m.action != DoNothing && !h.passableAt(danger, pos, tick) {
+				continue
+			}
+			if danger.DangerousAt(pos, tick) {
+				continue
+			}
+
+			next := timedStep{pos: pos, tick: min(tick, horizon)}
+			idx := index(next)
+			if visited[idx].prev != -2 {
+				continue
+			}
+			visited[idx] = link{prev: index(cur), action: m.action}
+
+			if goal(pos, tick) {
+				var actions []Action
+				for l := visited[idx]; l.prev >= 0; l = visited[l.prev] {
+					actions = append(actions, l.action)
+				}
+				for i, j := 0, len(actions)-1; i < j; i, j = i+1, j-1 {
+					actions[i], actions[j] = actions[j], actions[i]
+				}
+				return actions, true
+			}
+			queue = append(queue, next)
+		}
+	}
+	return nil, false
+}
+
+// passableAt reports whether pos can be entered on the given tick
+// A bomb blocks its cell until it has gone off
+func (h *GameHelpers) passableAt(danger *DangerMap, pos Position, tick int) bool {
+	if !h.inBounds(pos) || !walkableCell(h.State.Field.CellAt(pos)) {
+		return false
+	}
+	for _, b := range h.State.Bombs {
+		if b.Pos == pos {
+			exploded, ok := danger.bombTicks[pos]
+			return ok && exploded < tick
+		}
+	}
+	return true
+}
+
+func (h *GameHelpers) inBounds(pos Position) bool {
+	return pos.X >= 0 && pos.X < h.State.Field.Width && pos.Y >= 0 && pos.Y < h.State.Field.Height
+}
@@ -0,0 +1,120 @@
+package bombahead
+
+import (
+	"reflect"
+	"testing"
+)
+
+// walk applies actions from start and checks every step against the danger map
+func walk(t *testing.T, h *GameHelpers, start Position, actions []Action) Position {
+	t.Helper()
+
+	pos := start
+	for tick, a := range actions {
+		switch a {
+		case MoveUp:
+			pos.Y--
+		case MoveDown:
+			pos.Y++
+		case MoveLeft:
+			pos.X--
+		case MoveRight:
+			pos.X++
+		}
+		if h.DangerMap().DangerousAt(pos, tick+1) {
+			t.Fatalf("path %v stands in a blast at %v on tick %d", actions, pos, tick+1)
+		}
+	}
+	return pos
+}
+
+func TestSafePathTo(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		grid   string
+		target Position
+		want   []Action
+		ok     bool
+	}{
+		{
+			name: "open field",
+			grid: `
+				A....
+				.....`,
+			target: Position{X: 3, Y: 1},
+			want:   []Action{MoveRight, MoveRight, MoveRight, MoveDown},
+			ok:     true,
+		},
+		{
+			// Plain BFS would step onto 2,0 just as the bomb below explodes
+			name: "waits for the blast to pass",
+			grid: `
+				A....
+				##2##`,
+			target: Position{X: 4, Y: 0},
+			want:   []Action{MoveRight, DoNothing, MoveRight, MoveRight, MoveRight},
+			ok:     true,
+		},
+		{
+			name: "walks through a bomb after it exploded",
+			grid: `
+				A...1..`,
+			target: Position{X: 6, Y: 0},
+			want:   []Action{MoveRight, MoveRight, MoveRight, MoveRight, MoveRight, MoveRight},
+			ok:     true,
+		},
+		{
+			name: "no escape from the blast",
+			grid: `
+				A1.`,
+			target: Position{X: 2, Y: 0},
+			ok:     false,
+		},
+		{
+			name: "start is target",
+			grid: `
+				A..`,
+			target: Position{X: 0, Y: 0},
+			want:   []Action{},
+			ok:     true,
+		},
+		{
+			name: "unreachable",
+			grid: `
+				A#.`,
+			target: Position{X: 2, Y: 0},
+			ok:     false,
+		},
+	}
+	for _, tt := range tests {
+		state := MustParseASCII(tt.grid)
+		h := NewGameHelpers(state)
+		got, ok := h.SafePathTo(state.Me.Pos, tt.target)
+		if ok != tt.ok || (tt.want != nil && !reflect.DeepEqual(got, tt.want)) {
+			t.Fatalf("%s: SafePathTo() = %v, %v, want %v, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+		if ok {
+			if end := walk(t, h, state.Me.Pos, got); end != tt.target {
+				t.Fatalf("%s: path %v ends at %v, want %v", tt.name, got, end, tt.target)
+			}
+		}
+	}
+}
+
+func TestSafePathTo_AgreesWithIsWalkable(t *testing.T) {
+	t.Parallel()
+
+	state := MustParseASCII(`A..`)
+	state.Field.Cells[1] = CellType("GRASS")
+	h := NewGameHelpers(state)
+
+	if !h.IsWalkable(Position{X: 1}) {
+		t.Fatal("IsWalkable() = false for an unknown cell type, want true")
+	}
+	want := []Action{MoveRight, MoveRight}
+	if got, ok := h.SafePathTo(state.Me.Pos, Position{X: 2}); !ok || !reflect.DeepEqual(got, want) {
+		t.Fatalf("SafePathTo() = %v, %v, want %v, true", got, ok, want)
+	}
+}
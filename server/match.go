@@ -0,0 +1,129 @@
+package server
+
+import (
+	"log"
+	"time"
+
+	bombahead "github.com/N3moAhead/bombahead-go"
+	"github.com/N3moAhead/bombahead-go/sim"
+)
+
+// classicState is the classic_state payload as the SDK decodes it
+type classicState struct {
+	Tick       int                  `json:"tick"`
+	MaxTicks   int                  `json:"maxTicks"`
+	Players    []bombahead.Player   `json:"players"`
+	Field      fieldWire            `json:"field"`
+	Bombs      []bombahead.Bomb     `json:"bombs"`
+	Explosions []bombahead.Position `json:"explosions"`
+	Items      []bombahead.Item     `json:"items"`
+}
+
+type fieldWire struct {
+	Width  int                  `json:"width"`
+	Height int                  `json:"height"`
+	Field  []bombahead.CellType `json:"field"`
+}
+
+// spectatorState is the classic_state sent to spectators
+type spectatorState struct {
+	classicState
+	Actions map[string]bombahead.Action `json:"actions,omitempty"`
+}
+
+func newClassicState(s *bombahead.GameState, maxTicks int) classicState {
+	return classicState{
+		Tick:       s.CurrentTick,
+		MaxTicks:   maxTicks,
+		Players:    s.Players,
+		Field:      fieldWire{Width: s.Field.Width, Height: s.Field.Height, Field: s.Field.Cells},
+		Bombs:      s.Bombs,
+		Explosions: s.Explosions,
+		Items:      s.Items,
+	}
+}
+
+// runMatch plays one game between players and sends them back to the lobby
+func (s *Server) runMatch(gameID string, players []*client, seed uint64) {
+	ids := make([]string, len(players))
+	for i, c := range players {
+		ids[i] = c.id
+		c.takeMove()
+	}
+	log.Printf("Starting %s with %v", gameID, ids)
+
+	simulator := sim.New(s.cfg.Rules, seed)
+	state := simulator.NewGame(ids)
+	start := bombahead.GameStart{GameID: gameID, Players: state.Players}
+	for _, c := range players {
+		_ = c.send(msgGameStart, start)
+	}
+	s.spectate(msgGameStart, start)
+
+	ticker := time.NewTicker(s.cfg.TickInterval)
+	defer ticker.Stop()
+
+	var actions map[string]bombahead.Action
+	for {
+		wire := newClassicState(state, s.cfg.MaxTicks)
+		for _, c := range players {
+			_ = c.send(msgClassicState, wire)
+		}
+		s.spectate(msgClassicState, spectatorState{classicState: wire, Actions: actions})
+
+		if simulator.Done(state) || s.isClosed() {
+			break
+		}
+
+		<-ticker.C
+		actions = make(map[string]bombahead.Action, len(players))
+		for _, c := range players {
+			if s.isConnected(c) {
+				actions[c.id] = c.takeMove()
+			} else {
+				sim.Eliminate(state, c.id)
+			}
+		}
+		state, _ = simulator.Step(state, actions)
+	}
+
+	end := bombahead.GameEnd{Winner: sim.Winner(state), Scores: make(map[string]int, len(players))}
+	for _, p := range state.Players {
+		end.Scores[p.ID] = p.Score
+	}
+	log.Printf("Finished %s after %d ticks, winner %q", gameID, state.CurrentTick, end.Winner)
+
+	s.mu.Lock()
+	for _, c := range players {
+		c.ready = false
+		if c.id == end.Winner {
+			c.score++
+		}
+	}
+	s.inMatch = false
+	s.mu.Unlock()
+
+	for _, c := range players {
+		_ = c.send(msgBackToLobby, end)
+	}
+	s.spectate(msgBackToLobby, end)
+	s.broadcastLobby()
+	s.maybeStartMatch()
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+func (s *Server) isConnected(c *client) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, other := range s.clients {
+		if other == c {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,453 @@
+// Package server implements a local stand-in for the Bombahead game server
+//
+// It speaks the same WebSocket protocol as the real server, hosts matches between
+// the connected bots and needs no network access, so bots can play full games on a laptop.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	bombahead "github.com/N3moAhead/bombahead-go"
+	"github.com/N3moAhead/bombahead-go/sim"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	msgWelcome            = "welcome"
+	msgBackToLobby        = "back_to_lobby"
+	msgUpdateLobby        = "update_lobby"
+	msgPlayerStatusUpdate = "player_status_update"
+	msgServerError        = "error"
+	msgClassicInput       = "classic_input"
+	msgClassicState       = "classic_state"
+	msgGameStart          = "game_start"
+)
+
+// Config configures the server and the matches it hosts
+// Zero fields are replaced by the values of DefaultConfig, Rules as by sim.Rules.WithDefaults, so zero scores stay zero
+type Config struct {
+	// Addr is the listen address used by ListenAndServe
+	Addr string
+	// Token, if set, is the only accepted bearer token
+	Token string
+	// PlayersPerMatch is the number of ready clients needed to start a match
+	PlayersPerMatch int
+	// TickInterval is the time bots have to answer each classic_state
+	TickInterval time.Duration
+	// Rules are the simulator rules of the matches, odd Width and Height give the classic pillar layout
+	sim.Rules
+	// Seed makes board generation reproducible; zero picks a random seed
+	Seed uint64
+}
+
+// DefaultConfig returns the configuration used for zero fields
+func DefaultConfig() Config {
+	return Config{
+		Addr:            ":8038",
+		PlayersPerMatch: 2,
+		TickInterval:    200 * time.Millisecond,
+		Rules:           sim.DefaultRules(),
+	}
+}
+
+func (c Config) withDefaults() Config {
+	def := DefaultConfig()
+	if c.Addr == "" {
+		c.Addr = def.Addr
+	}
+	if c.PlayersPerMatch <= 0 {
+		c.PlayersPerMatch = def.PlayersPerMatch
+	}
+	if c.TickInterval <= 0 {
+		c.TickInterval = def.TickInterval
+	}
+	c.Rules = c.Rules.WithDefaults()
+	// Smaller boards leave no room for the start corners
+	if c.Width < 5 {
+		c.Width = def.Width
+	}
+	if c.Height < 5 {
+		c.Height = def.Height
+	}
+	if c.BoxDensity < 0 || c.BoxDensity > 1 {
+		c.BoxDensity = def.BoxDensity
+	}
+	if c.Seed == 0 {
+		c.Seed = rand.Uint64()
+	}
+	return c
+}
+
+// Server hosts a lobby and runs matches between the connected clients
+type Server struct {
+	cfg      Config
+	upgrader websocket.Upgrader
+	rng      *rand.Rand
+
+	mu      sync.Mutex
+	clients []*client
+	// spectators receive every match but never play
+	spectators []*client
+	nextID     int
+	nextGame   int
+	inMatch    bool
+	closed     bool
+}
+
+// New returns a server for cfg
+func New(cfg Config) *Server {
+	cfg = cfg.withDefaults()
+	return &Server{
+		cfg: cfg,
+		rng: rand.New(rand.NewPCG(cfg.Seed, cfg.Seed^0x9e3779b97f4a7c15)),
+	}
+}
+
+// Config returns the effective configuration
+func (s *Server) Config() Config {
+	return s.cfg
+}
+
+// ListenAndServe serves the protocol on cfg.Addr at /ws, and spectators at /spectate, until ctx is cancelled
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	mux := http.NewServeMux()
+	mux.Handle("/ws", s)
+	mux.HandleFunc("/spectate", s.ServeSpectator)
+	httpServer := &http.Server{Addr: s.cfg.Addr, Handler: mux}
+
+	stop := context.AfterFunc(ctx, func() {
+		s.Close()
+		_ = httpServer.Close()
+	})
+	defer stop()
+
+	log.Printf("Listening on ws://%s/ws", s.cfg.Addr)
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return ctx.Err()
+}
+
+// Close disconnects every client
+func (s *Server) Close() {
+	s.mu.Lock()
+	s.closed = true
+	clients := append(append([]*client(nil), s.clients...), s.spectators...)
+	s.mu.Unlock()
+
+	for _, c := range clients {
+		c.close(websocket.CloseGoingAway, "server shutting down")
+	}
+}
+
+// ServeHTTP upgrades the request to a WebSocket and serves one client
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn := s.upgrade(w, r)
+	if conn == nil {
+		return
+	}
+
+	c := s.register(conn)
+	if c == nil {
+		closeConn(conn, websocket.CloseTryAgainLater, "server is shut down, not accepting players")
+		return
+	}
+	var err error
+	defer func() { s.unregister(c, err) }()
+
+	if err = c.send(msgWelcome, map[string]string{"clientId": c.id}); err != nil {
+		return
+	}
+	s.broadcastLobby()
+	err = c.readLoop(s)
+}
+
+// ServeSpectator upgrades the request to a WebSocket for a spectator
+// Spectators receive game_start, classic_state and back_to_lobby of every match
+// Their classic_state also carries the actions that led to it, keyed by player ID
+func (s *Server) ServeSpectator(w http.ResponseWriter, r *http.Request) {
+	conn := s.upgrade(w, r)
+	if conn == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		closeConn(conn, websocket.CloseTryAgainLater, "server is shut down, not accepting spectators")
+		return
+	}
+	s.nextID++
+	c := &client{id: fmt.Sprintf("spectator-%d", s.nextID), conn: conn}
+	s.spectators = append(s.spectators, c)
+	s.mu.Unlock()
+	log.Printf("%s connected", c.id)
+
+	var err error
+	defer func() {
+		c.close(closeFrame(err))
+		s.mu.Lock()
+		for i, other := range s.spectators {
+			if other == c {
+				s.spectators = append(s.spectators[:i], s.spectators[i+1:]...)
+				break
+			}
+		}
+		s.mu.Unlock()
+		log.Printf("%s disconnected", c.id)
+	}()
+
+	if err = c.send(msgWelcome, map[string]string{"clientId": c.id}); err != nil {
+		return
+	}
+	for {
+		if _, _, err = conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+// upgrade checks the bearer token and upgrades the request, answering it on failure
+func (s *Server) upgrade(w http.ResponseWriter, r *http.Request) *websocket.Conn {
+	if s.cfg.Token != "" && strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ") != s.cfg.Token {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return nil
+	}
+
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("Upgrade failed: %v", err)
+		return nil
+	}
+	return conn
+}
+
+// spectate sends a message to every spectator
+func (s *Server) spectate(msgType string, payload any) {
+	s.mu.Lock()
+	spectators := append([]*client(nil), s.spectators...)
+	s.mu.Unlock()
+
+	for _, c := range spectators {
+		_ = c.send(msgType, payload)
+	}
+}
+
+func (s *Server) register(conn *websocket.Conn) *client {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+
+	s.nextID++
+	c := &client{id: fmt.Sprintf("player-%d", s.nextID), conn: conn}
+	s.clients = append(s.clients, c)
+	log.Printf("%s connected", c.id)
+	return c
+}
+
+// unregister removes c after its connection ended with err
+func (s *Server) unregister(c *client, err error) {
+	c.close(closeFrame(err))
+
+	s.mu.Lock()
+	for i, other := range s.clients {
+		if other == c {
+			s.clients = append(s.clients[:i], s.clients[i+1:]...)
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	log.Printf("%s disconnected", c.id)
+	s.broadcastLobby()
+}
+
+// handle processes one message from c
+func (s *Server) handle(c *client, msg *bombahead.Message) {
+	switch msg.Type {
+	case msgPlayerStatusUpdate:
+		var payload struct {
+			IsReady bool `json:"isReady"`
+		}
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+			_ = c.send(msgServerError, map[string]string{"message": "invalid player_status_update payload"})
+			return
+		}
+		s.mu.Lock()
+		c.ready = payload.IsReady
+		s.mu.Unlock()
+		s.broadcastLobby()
+		s.maybeStartMatch()
+
+	case msgClassicInput:
+		var payload struct {
+			Move bombahead.Action `json:"move"`
+		}
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil || !validAction(payload.Move) {
+			_ = c.send(msgServerError, map[string]string{"message": fmt.Sprintf("invalid move %s", string(msg.Payload))})
+			return
+		}
+		c.setMove(payload.Move)
+
+	default:
+		_ = c.send(msgServerError, map[string]string{"message": fmt.Sprintf("unknown message type %q", msg.Type)})
+	}
+}
+
+func (s *Server) broadcastLobby() {
+	s.mu.Lock()
+	lobby := bombahead.Lobby{Players: make([]bombahead.LobbyPlayer, 0, len(s.clients))}
+	clients := append([]*client(nil), s.clients...)
+	for _, c := range s.clients {
+		lobby.Players = append(lobby.Players, bombahead.LobbyPlayer{ID: c.id, Name: c.id, IsReady: c.ready, Score: c.score})
+	}
+	s.mu.Unlock()
+
+	for _, c := range clients {
+		_ = c.send(msgUpdateLobby, lobby)
+	}
+}
+
+// maybeStartMatch starts a match when enough clients are ready and none is running
+func (s *Server) maybeStartMatch() {
+	s.mu.Lock()
+	if s.inMatch || s.closed {
+		s.mu.Unlock()
+		return
+	}
+	var players []*client
+	for _, c := range s.clients {
+		if c.ready && len(players) < s.cfg.PlayersPerMatch {
+			players = append(players, c)
+		}
+	}
+	if len(players) < s.cfg.PlayersPerMatch {
+		s.mu.Unlock()
+		return
+	}
+	s.inMatch = true
+	s.nextGame++
+	gameID := fmt.Sprintf("game-%d", s.nextGame)
+	seed := s.rng.Uint64()
+	s.mu.Unlock()
+
+	go s.runMatch(gameID, players, seed)
+}
+
+func validAction(a bombahead.Action) bool {
+	switch a {
+	case bombahead.MoveUp, bombahead.MoveDown, bombahead.MoveLeft, bombahead.MoveRight, bombahead.PlaceBomb, bombahead.DoNothing:
+		return true
+	default:
+		return false
+	}
+}
+
+// client is one connected WebSocket
+type client struct {
+	id   string
+	conn *websocket.Conn
+
+	writeMu sync.Mutex
+
+	// ready and score are guarded by Server.mu
+	ready bool
+	score int
+
+	moveMu sync.Mutex
+	move   bombahead.Action
+
+	closeOnce sync.Once
+}
+
+func (c *client) send(msgType string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal payload: %w", err)
+	}
+	envelope, err := json.Marshal(bombahead.Message{Type: msgType, Payload: data})
+	if err != nil {
+		return fmt.Errorf("marshal message: %w", err)
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.WriteMessage(websocket.TextMessage, envelope)
+}
+
+// readLoop handles messages until reading fails and returns that error
+func (c *client) readLoop(s *Server) error {
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		var msg bombahead.Message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			_ = c.send(msgServerError, map[string]string{"message": "invalid message envelope"})
+			continue
+		}
+		s.handle(c, &msg)
+	}
+}
+
+func (c *client) setMove(a bombahead.Action) {
+	c.moveMu.Lock()
+	defer c.moveMu.Unlock()
+	c.move = a
+}
+
+// takeMove returns the last move received since the previous call
+func (c *client) takeMove() bombahead.Action {
+	c.moveMu.Lock()
+	defer c.moveMu.Unlock()
+	a := c.move
+	c.move = bombahead.DoNothing
+	return a
+}
+
+// close sends a close frame with code and reason and closes the connection, only the first call has an effect
+func (c *client) close(code int, reason string) {
+	c.closeOnce.Do(func() {
+		c.writeMu.Lock()
+		defer c.writeMu.Unlock()
+		closeConn(c.conn, code, reason)
+	})
+}
+
+// maxCloseReason is the longest reason that fits into a close frame next to its code
+const maxCloseReason = 123
+
+// closeConn sends a close frame and closes conn, the caller must hold its write lock if there is one
+func closeConn(conn *websocket.Conn, code int, reason string) {
+	if len(reason) > maxCloseReason {
+		reason = reason[:maxCloseReason]
+	}
+	_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason))
+	_ = conn.Close()
+}
+
+// closeFrame returns the close code and reason for a connection that ended with err
+// A peer that closed the connection itself gets a normal closure back
+func closeFrame(err error) (int, string) {
+	var closeErr *websocket.CloseError
+	switch {
+	case err == nil:
+		return websocket.CloseNormalClosure, ""
+	case errors.As(err, &closeErr):
+		return websocket.CloseNormalClosure, "connection closed by client"
+	default:
+		return websocket.CloseInternalServerErr, err.Error()
+	}
+}
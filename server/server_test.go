@@ -0,0 +1,277 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	bombahead "github.com/N3moAhead/bombahead-go"
+	"github.com/N3moAhead/bombahead-go/sim"
+	"github.com/gorilla/websocket"
+)
+
+type endRecorder struct {
+	action bombahead.Action
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	start  bombahead.GameStart
+	end    bombahead.GameEnd
+	states int
+}
+
+func (b *endRecorder) GetNextMove(*bombahead.GameState, *bombahead.GameHelpers) bombahead.Action {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.states++
+	return b.action
+}
+
+func (b *endRecorder) OnGameStart(start bombahead.GameStart) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.start = start
+}
+
+func (b *endRecorder) OnGameEnd(end bombahead.GameEnd) {
+	b.mu.Lock()
+	b.end = end
+	b.mu.Unlock()
+	b.cancel()
+}
+
+func TestServer_PlaysMatchBetweenBots(t *testing.T) {
+	t.Parallel()
+
+	srv := New(Config{TickInterval: 2 * time.Millisecond, Rules: sim.Rules{MaxTicks: 15}, Seed: 7})
+	httpServer := httptest.NewServer(srv)
+	defer httpServer.Close()
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(httpServer.URL, "http")
+
+	var wg sync.WaitGroup
+	bots := make([]*endRecorder, 2)
+	for i := range bots {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		bots[i] = &endRecorder{action: bombahead.DoNothing, cancel: cancel}
+
+		wg.Add(1)
+		go func(bot *endRecorder) {
+			defer wg.Done()
+			err := bombahead.RunContext(ctx, bot, bombahead.WithURL(url))
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("RunContext() error = %v, want context.Canceled after game end", err)
+			}
+		}(bots[i])
+	}
+	wg.Wait()
+
+	for i, bot := range bots {
+		bot.mu.Lock()
+		if bot.start.GameID == "" || len(bot.start.Players) != 2 {
+			t.Errorf("bot %d start = %+v, want game with two players", i, bot.start)
+		}
+		if bot.end.LastState == nil || bot.end.LastState.CurrentTick != 15 {
+			t.Errorf("bot %d last state = %+v, want tick 15", i, bot.end.LastState)
+		}
+		if len(bot.end.Scores) != 2 {
+			t.Errorf("bot %d scores = %v, want two entries", i, bot.end.Scores)
+		}
+		if bot.states == 0 {
+			t.Errorf("bot %d was never asked for a move", i)
+		}
+		bot.mu.Unlock()
+	}
+}
+
+func TestServer_BoxScore(t *testing.T) {
+	t.Parallel()
+
+	for _, boxScore := range []int{0, 1} {
+		srv := New(Config{TickInterval: 2 * time.Millisecond, Rules: sim.Rules{BoxDensity: 1, BoxScore: boxScore, MaxTicks: 10}, Seed: 5})
+		if got := srv.Config().BoxScore; got != boxScore {
+			t.Fatalf("Config().BoxScore = %d, want %d", got, boxScore)
+		}
+		httpServer := httptest.NewServer(srv)
+		url := "ws" + strings.TrimPrefix(httpServer.URL, "http")
+
+		// Both bots bomb their own corner, which only ever destroys boxes and hits themselves
+		var wg sync.WaitGroup
+		bots := make([]*endRecorder, 2)
+		for i := range bots {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			bots[i] = &endRecorder{action: bombahead.PlaceBomb, cancel: cancel}
+
+			wg.Add(1)
+			go func(bot *endRecorder) {
+				defer wg.Done()
+				_ = bombahead.RunContext(ctx, bot, bombahead.WithURL(url))
+			}(bots[i])
+		}
+		wg.Wait()
+		srv.Close()
+		httpServer.Close()
+
+		end := bots[0].end
+		if end.LastState == nil || end.LastState.Field.CellAt(bombahead.Position{X: 3, Y: 1}) != bombahead.Air {
+			t.Fatalf("BoxScore %d: last state = %v, want the box at 3,1 destroyed", boxScore, end.LastState)
+		}
+		for id, score := range end.Scores {
+			if want := 2 * boxScore; score != want {
+				t.Fatalf("BoxScore %d: score of %s = %d, want %d", boxScore, id, score, want)
+			}
+		}
+	}
+}
+
+func TestServer_RejectsWrongToken(t *testing.T) {
+	t.Parallel()
+
+	srv := New(Config{Token: "secret"})
+	httpServer := httptest.NewServer(srv)
+	defer httpServer.Close()
+
+	err := bombahead.RunContext(context.Background(), &endRecorder{},
+		bombahead.WithURL("ws"+strings.TrimPrefix(httpServer.URL, "http")),
+		bombahead.WithToken("wrong"),
+	)
+	if !errors.Is(err, bombahead.ErrAuthRejected) {
+		t.Fatalf("RunContext() error = %v, want ErrAuthRejected", err)
+	}
+}
+
+func TestServer_CloseReasons(t *testing.T) {
+	t.Parallel()
+
+	srv := New(Config{})
+	httpServer := httptest.NewServer(srv)
+	defer httpServer.Close()
+	url := "ws" + strings.TrimPrefix(httpServer.URL, "http")
+
+	closeText := func(conn *websocket.Conn) string {
+		t.Helper()
+		for {
+			_, _, err := conn.ReadMessage()
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) {
+				return closeErr.Text
+			}
+			if err != nil {
+				t.Fatalf("ReadMessage() error = %v, want a close frame", err)
+			}
+		}
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+	if _, _, err := conn.ReadMessage(); err != nil {
+		t.Fatalf("reading welcome: %v", err)
+	}
+	srv.Close()
+	if got, want := closeText(conn), "server shutting down"; got != want {
+		t.Fatalf("close reason = %q, want %q", got, want)
+	}
+
+	late, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer late.Close()
+	if got, want := closeText(late), "server is shut down, not accepting players"; got != want {
+		t.Fatalf("close reason = %q, want %q", got, want)
+	}
+}
+
+func TestCloseFrame(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		err    error
+		code   int
+		reason string
+	}{
+		{nil, websocket.CloseNormalClosure, ""},
+		{&websocket.CloseError{Code: websocket.CloseGoingAway}, websocket.CloseNormalClosure, "connection closed by client"},
+		{errors.New("read tcp: reset"), websocket.CloseInternalServerErr, "read tcp: reset"},
+	}
+	for _, tt := range tests {
+		if code, reason := closeFrame(tt.err); code != tt.code || reason != tt.reason {
+			t.Fatalf("closeFrame(%v) = %d, %q, want %d, %q", tt.err, code, reason, tt.code, tt.reason)
+		}
+	}
+}
+
+func TestServer_Spectator(t *testing.T) {
+	t.Parallel()
+
+	srv := New(Config{TickInterval: 2 * time.Millisecond, Rules: sim.Rules{MaxTicks: 10}, Seed: 3})
+	mux := http.NewServeMux()
+	mux.Handle("/ws", srv)
+	mux.HandleFunc("/spectate", srv.ServeSpectator)
+	httpServer := httptest.NewServer(mux)
+	defer httpServer.Close()
+	defer srv.Close()
+	base := "ws" + strings.TrimPrefix(httpServer.URL, "http")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	spectator, err := bombahead.WebSocketDialer(ctx, base+"/spectate", "")
+	if err != nil {
+		t.Fatalf("dial spectator: %v", err)
+	}
+	defer spectator.Close()
+	if msg, err := spectator.ReadMessage(); err != nil || msg.Type != msgWelcome {
+		t.Fatalf("first spectator message = %v, %v, want welcome", msg, err)
+	}
+
+	for i := 0; i < 2; i++ {
+		go func() {
+			_ = bombahead.RunContext(ctx, &endRecorder{action: bombahead.MoveDown, cancel: cancel}, bombahead.WithURL(base+"/ws"))
+		}()
+	}
+
+	var types []string
+	withActions := 0
+	for {
+		msg, err := spectator.ReadMessage()
+		if err != nil {
+			t.Fatalf("spectator read: %v (got %v)", err, types)
+		}
+		if msg.Type == msgUpdateLobby {
+			t.Fatalf("spectator received %s, want only match messages", msg.Type)
+		}
+		types = append(types, msg.Type)
+		if msg.Type == msgClassicState {
+			var state struct {
+				Actions map[string]bombahead.Action `json:"actions"`
+			}
+			if err := json.Unmarshal(msg.Payload, &state); err != nil {
+				t.Fatalf("unmarshal spectator state: %v", err)
+			}
+			if len(state.Actions) == 2 {
+				withActions++
+			}
+		}
+		if msg.Type == msgBackToLobby {
+			break
+		}
+	}
+
+	if types[0] != msgGameStart || len(types) != 13 {
+		t.Fatalf("spectator messages = %v, want game_start, 11 states and back_to_lobby", types)
+	}
+	if withActions != 10 {
+		t.Fatalf("%d states carried both actions, want 10", withActions)
+	}
+}
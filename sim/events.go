@@ -0,0 +1,39 @@
+package sim
+
+import bombahead "github.com/N3moAhead/bombahead-go"
+
+// EventKind describes what happened in an Event
+type EventKind string
+
+const (
+	EventBombPlaced       EventKind = "bomb_placed"
+	EventBombExploded     EventKind = "bomb_exploded"
+	EventBoxDestroyed     EventKind = "box_destroyed"
+	EventPlayerHit        EventKind = "player_hit"
+	EventPlayerEliminated EventKind = "player_eliminated"
+)
+
+// Event is one thing that happened during a Step
+type Event struct {
+	Tick int       `json:"tick"`
+	Kind EventKind `json:"kind"`
+	// Player is the acting player: who placed the bomb, or the owner of the blast
+	Player string `json:"player,omitempty"`
+	// Target is the player that was hit or eliminated
+	Target string             `json:"target,omitempty"`
+	Pos    bombahead.Position `json:"pos"`
+}
+
+// Events lists the events of one or more steps in the order they happened
+type Events []Event
+
+// Filter returns the events of the given kind
+func (e Events) Filter(kind EventKind) Events {
+	var out Events
+	for _, ev := range e {
+		if ev.Kind == kind {
+			out = append(out, ev)
+		}
+	}
+	return out
+}
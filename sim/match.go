@@ -0,0 +1,139 @@
+package sim
+
+import (
+	"fmt"
+	"log"
+
+	bombahead "github.com/N3moAhead/bombahead-go"
+)
+
+// MatchConfig configures RunMatch
+type MatchConfig struct {
+	// Rules fields that are zero take their DefaultRules value, except BoxDensity and the scores,
+	// so every match ends after MaxTicks
+	Rules Rules
+	// Seed selects the board
+	Seed uint64
+	// PlayerIDs names the bots in order; missing IDs default to "p1", "p2", ...
+	PlayerIDs []string
+}
+
+// PlayerResult is the final standing of one bot
+type PlayerResult struct {
+	ID     string
+	Score  int
+	Health int
+	// Panics counts ticks where the bot panicked and did nothing instead
+	Panics int
+}
+
+// MatchResult is the outcome of RunMatch
+type MatchResult struct {
+	// Winner is the ID of the winning bot, or "" for a draw
+	Winner string
+	// WinnerIndex is the index of the winning bot in the bots slice, or -1 for a draw
+	WinnerIndex int
+	// Ticks is the number of ticks played
+	Ticks int
+	// Players lists the results in the order of the bots slice
+	Players []PlayerResult
+	// Events is the full event log of the match
+	Events Events
+	// FinalState is the state after the last tick
+	FinalState *bombahead.GameState
+}
+
+// RunMatch plays one game between bots without a server
+// Every tick each living bot receives its own copy of the state, with Me and Opponents set
+// from its perspective and fresh GameHelpers. Bots implementing GameStartListener or
+// GameEndListener are notified like by the client. A panic inside GetNextMove is recovered
+// and counted, and the bot does nothing for that tick
+func RunMatch(bots []bombahead.Bot, cfg MatchConfig) MatchResult {
+	ids := make([]string, len(bots))
+	for i := range bots {
+		if i < len(cfg.PlayerIDs) && cfg.PlayerIDs[i] != "" {
+			ids[i] = cfg.PlayerIDs[i]
+		} else {
+			ids[i] = fmt.Sprintf("p%d", i+1)
+		}
+	}
+
+	s := New(cfg.Rules.WithDefaults(), cfg.Seed)
+	state := s.NewGame(ids)
+	panics := make([]int, len(bots))
+	var eventLog Events
+
+	for _, bot := range bots {
+		if l, ok := bot.(bombahead.GameStartListener); ok {
+			l.OnGameStart(bombahead.GameStart{GameID: fmt.Sprintf("sim-%d", cfg.Seed), Players: clone(state).Players})
+		}
+	}
+
+	for !s.Done(state) {
+		actions := make(map[string]bombahead.Action, len(bots))
+		for i, bot := range bots {
+			if state.Players[i].Health <= 0 {
+				continue
+			}
+			action, ok := callBot(bot, View(state, ids[i]))
+			if !ok {
+				panics[i]++
+			}
+			actions[ids[i]] = action
+		}
+
+		var events Events
+		state, events = s.Step(state, actions)
+		eventLog = append(eventLog, events...)
+	}
+
+	result := MatchResult{
+		Winner:      Winner(state),
+		WinnerIndex: -1,
+		Ticks:       state.CurrentTick,
+		Players:     make([]PlayerResult, len(bots)),
+		Events:      eventLog,
+		FinalState:  state,
+	}
+	scores := make(map[string]int, len(bots))
+	for i, p := range state.Players {
+		result.Players[i] = PlayerResult{ID: p.ID, Score: p.Score, Health: p.Health, Panics: panics[i]}
+		scores[p.ID] = p.Score
+		if p.ID == result.Winner {
+			result.WinnerIndex = i
+		}
+	}
+
+	for i, bot := range bots {
+		if l, ok := bot.(bombahead.GameEndListener); ok {
+			l.OnGameEnd(bombahead.GameEnd{Winner: result.Winner, Scores: scores, LastState: View(state, ids[i])})
+		}
+	}
+
+	return result
+}
+
+// View returns a copy of state from the perspective of the player with the given ID
+func View(state *bombahead.GameState, id string) *bombahead.GameState {
+	view := clone(state)
+	for _, p := range view.Players {
+		if p.ID == id {
+			me := p
+			view.Me = &me
+			continue
+		}
+		view.Opponents = append(view.Opponents, p)
+	}
+	return view
+}
+
+// callBot asks bot for a move and reports false if it panicked
+func callBot(bot bombahead.Bot, view *bombahead.GameState) (action bombahead.Action, ok bool) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			log.Printf("Bot %s panicked on tick %d: %v", view.Me.ID, view.CurrentTick, rec)
+			action, ok = bombahead.DoNothing, false
+		}
+	}()
+	return bot.GetNextMove(view, bombahead.NewGameHelpers(view)), true
+}
@@ -0,0 +1,132 @@
+package sim
+
+import (
+	"reflect"
+	"testing"
+
+	bombahead "github.com/N3moAhead/bombahead-go"
+)
+
+type idleBot struct {
+	seen []string
+}
+
+func (b *idleBot) GetNextMove(state *bombahead.GameState, _ *bombahead.GameHelpers) bombahead.Action {
+	b.seen = append(b.seen, state.Me.ID)
+	return bombahead.DoNothing
+}
+
+// hunterBot walks towards the first opponent and bombs it when adjacent
+type hunterBot struct{}
+
+func (hunterBot) GetNextMove(state *bombahead.GameState, h *bombahead.GameHelpers) bombahead.Action {
+	me := state.Me.Pos
+	if !h.IsSafe(me) {
+		return bombahead.SafeFallback(state, h)
+	}
+	for _, o := range state.Opponents {
+		if o.Health <= 0 {
+			continue
+		}
+		if me.DistanceTo(o.Pos) <= 1 {
+			return bombahead.PlaceBomb
+		}
+		return h.GetNextActionTowards(me, o.Pos)
+	}
+	return bombahead.DoNothing
+}
+
+type panicBot struct{}
+
+func (panicBot) GetNextMove(*bombahead.GameState, *bombahead.GameHelpers) bombahead.Action {
+	panic("boom")
+}
+
+func matchRules() Rules {
+	rules := DefaultRules()
+	rules.Width, rules.Height = 7, 7
+	rules.BoxDensity = 0
+	rules.MaxTicks = 60
+	return rules
+}
+
+func TestRunMatch_IdleBotsDraw(t *testing.T) {
+	t.Parallel()
+
+	a, b := &idleBot{}, &idleBot{}
+	result := RunMatch([]bombahead.Bot{a, b}, MatchConfig{Rules: matchRules(), PlayerIDs: []string{"alice"}})
+
+	if result.Winner != "" || result.WinnerIndex != -1 {
+		t.Fatalf("winner = %q (%d), want draw", result.Winner, result.WinnerIndex)
+	}
+	if result.Ticks != 60 {
+		t.Fatalf("Ticks = %d, want 60", result.Ticks)
+	}
+	if result.Players[0].ID != "alice" || result.Players[1].ID != "p2" {
+		t.Fatalf("player IDs = %s, %s, want alice, p2", result.Players[0].ID, result.Players[1].ID)
+	}
+	if len(a.seen) != 60 || a.seen[0] != "alice" || b.seen[0] != "p2" {
+		t.Fatalf("views: a saw %d states as %v, b as %v", len(a.seen), a.seen[:1], b.seen[:1])
+	}
+}
+
+func TestRunMatch_HunterBeatsIdleBot(t *testing.T) {
+	t.Parallel()
+
+	rules := matchRules()
+	rules.MaxTicks = 300
+	result := RunMatch([]bombahead.Bot{hunterBot{}, &idleBot{}}, MatchConfig{Rules: rules, Seed: 3})
+
+	if result.Winner != "p1" || result.WinnerIndex != 0 {
+		t.Fatalf("winner = %q (%d), want p1; players %+v", result.Winner, result.WinnerIndex, result.Players)
+	}
+	if result.Players[1].Health != 0 {
+		t.Fatalf("idle bot health = %d, want 0", result.Players[1].Health)
+	}
+	if len(result.Events.Filter(EventPlayerEliminated)) != 1 {
+		t.Fatalf("eliminations = %+v, want one", result.Events.Filter(EventPlayerEliminated))
+	}
+	if result.Ticks >= rules.MaxTicks {
+		t.Fatalf("Ticks = %d, want the match to end before MaxTicks", result.Ticks)
+	}
+}
+
+func TestRunMatch_Deterministic(t *testing.T) {
+	t.Parallel()
+
+	rules := DefaultRules()
+	rules.MaxTicks = 100
+	cfg := MatchConfig{Rules: rules, Seed: 9}
+	first := RunMatch([]bombahead.Bot{hunterBot{}, hunterBot{}}, cfg)
+	second := RunMatch([]bombahead.Bot{hunterBot{}, hunterBot{}}, cfg)
+
+	if !reflect.DeepEqual(first, second) {
+		t.Fatal("RunMatch() with the same seed and bots gave different results")
+	}
+}
+
+func TestRunMatch_RecoversPanics(t *testing.T) {
+	t.Parallel()
+
+	rules := matchRules()
+	rules.MaxTicks = 5
+	result := RunMatch([]bombahead.Bot{panicBot{}, &idleBot{}}, MatchConfig{Rules: rules})
+	if result.Players[0].Panics != 5 {
+		t.Fatalf("Panics = %d, want 5", result.Players[0].Panics)
+	}
+}
+
+func TestRunMatch_DefaultsZeroRules(t *testing.T) {
+	t.Parallel()
+
+	rules := matchRules()
+	rules.MaxTicks = 0
+	rules.StartHealth = 0
+	result := RunMatch([]bombahead.Bot{&idleBot{}, &idleBot{}}, MatchConfig{Rules: rules})
+	if result.Ticks != DefaultRules().MaxTicks {
+		t.Fatalf("Ticks = %d, want the default MaxTicks %d", result.Ticks, DefaultRules().MaxTicks)
+	}
+	if result.Players[0].Health != DefaultRules().StartHealth {
+		t.Fatalf("Health = %d, want the default StartHealth", result.Players[0].Health)
+	}
+}
@@ -0,0 +1,395 @@
+// Package sim advances a bombahead.GameState without a server
+//
+// The rules mirror the local server: moves are applied in player order, then fuses burn
+// down and bombs at zero explode, setting off every bomb inside their blast. Blasts use
+// bombahead.BlastCells, the same logic GameHelpers uses for its danger predictions.
+// Bomb range and capacity are per player, starting from Rules. Power-up items are carried
+// over unchanged, the simulator neither spawns nor collects them.
+package sim
+
+import (
+	"cmp"
+	"math/rand/v2"
+
+	bombahead "github.com/N3moAhead/bombahead-go"
+)
+
+// Rules configures the simulated game
+type Rules struct {
+	// Width and Height are the board size used by NewGame
+	Width, Height int
+	// BoxDensity is the chance that a free cell starts with a box
+	BoxDensity float64
+	// BombFuse is the fuse of a freshly placed bomb
+	BombFuse int
+	// BombRange is the blast range in cells
+	BombRange int
+	// StartHealth is the health players start with
+	StartHealth int
+	// MaxBombs is the number of bombs a player may have on the field at once
+	MaxBombs int
+	// BoxScore is awarded to the owner of the bomb that destroys a box
+	BoxScore int
+	// HitScore is awarded to the owner of the bomb that hits an opponent
+	HitScore int
+	// MaxTicks ends the game when it is reached
+	MaxTicks int
+}
+
+// DefaultRules returns the rules of the local server
+func DefaultRules() Rules {
+	return Rules{
+		Width:       11,
+		Height:      11,
+		BoxDensity:  0.6,
+		BombFuse:    3,
+		BombRange:   2,
+		StartHealth: 3,
+		MaxBombs:    1,
+		BoxScore:    1,
+		HitScore:    5,
+		MaxTicks:    300,
+	}
+}
+
+// WithDefaults fills the sizes, limits and MaxTicks that are zero or negative from DefaultRules
+// BoxDensity and the scores are kept, as zero is a meaningful value for them
+func (r Rules) WithDefaults() Rules {
+	def := DefaultRules()
+	if r.Width <= 0 {
+		r.Width = def.Width
+	}
+	if r.Height <= 0 {
+		r.Height = def.Height
+	}
+	if r.BombFuse <= 0 {
+		r.BombFuse = def.BombFuse
+	}
+	if r.BombRange <= 0 {
+		r.BombRange = def.BombRange
+	}
+	if r.StartHealth <= 0 {
+		r.StartHealth = def.StartHealth
+	}
+	if r.MaxBombs <= 0 {
+		r.MaxBombs = def.MaxBombs
+	}
+	if r.MaxTicks <= 0 {
+		r.MaxTicks = def.MaxTicks
+	}
+	return r
+}
+
+// Simulator applies Rules to game states
+// It keeps no state between calls, so Step may be called on any state, any number of times
+type Simulator struct {
+	rules Rules
+	seed  uint64
+}
+
+// New returns a simulator for rules whose boards are generated from seed
+func New(rules Rules, seed uint64) *Simulator {
+	return &Simulator{rules: rules, seed: seed}
+}
+
+// Rules returns the rules of the simulator
+func (s *Simulator) Rules() Rules {
+	return s.rules
+}
+
+var directions = []bombahead.Position{
+	{X: 0, Y: -1},
+	{X: 1, Y: 0},
+	{X: 0, Y: 1},
+	{X: -1, Y: 0},
+}
+
+// NewGame builds a classic board: a wall border, wall pillars on even coordinates,
+// random boxes and free corners for the spawns
+// The returned state has no Me; every player is listed in Players
+func (s *Simulator) NewGame(playerIDs []string) *bombahead.GameState {
+	rng := rand.New(rand.NewPCG(s.seed, s.seed^0x9e3779b97f4a7c15))
+	w, h := s.rules.Width, s.rules.Height
+
+	cells := make([]bombahead.CellType, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			switch {
+			case x == 0 || y == 0 || x == w-1 || y == h-1, x%2 == 0 && y%2 == 0:
+				cells[y*w+x] = bombahead.Wall
+			case rng.Float64() < s.rules.BoxDensity:
+				cells[y*w+x] = bombahead.Box
+			default:
+				cells[y*w+x] = bombahead.Air
+			}
+		}
+	}
+
+	spawns := []bombahead.Position{
+		{X: 1, Y: 1},
+		{X: w - 2, Y: h - 2},
+		{X: w - 2, Y: 1},
+		{X: 1, Y: h - 2},
+	}
+	players := make([]bombahead.Player, len(playerIDs))
+	for i, id := range playerIDs {
+		pos := spawns[i%len(spawns)]
+		players[i] = bombahead.Player{ID: id, Pos: pos, Health: s.rules.StartHealth, BombRange: s.rules.BombRange, MaxBombs: s.rules.MaxBombs}
+		// Clear the spawn and its neighbours so nobody starts boxed in
+		for _, d := range append([]bombahead.Position{{}}, directions...) {
+			n := bombahead.Position{X: pos.X + d.X, Y: pos.Y + d.Y}
+			if n.X > 0 && n.Y > 0 && n.X < w-1 && n.Y < h-1 && cells[n.Y*w+n.X] == bombahead.Box {
+				cells[n.Y*w+n.X] = bombahead.Air
+			}
+		}
+	}
+
+	return &bombahead.GameState{
+		MaxTicks: s.rules.MaxTicks,
+		Players:  players,
+		Field:    bombahead.Field{Width: w, Height: h, Cells: cells},
+	}
+}
+
+// Step returns the state one tick after state, and what happened during the tick
+// state is not modified. Players without an entry in actions do nothing
+func (s *Simulator) Step(state *bombahead.GameState, actions map[string]bombahead.Action) (*bombahead.GameState, Events) {
+	next := clone(state)
+	next.CurrentTick++
+	next.Explosions = nil
+	var events Events
+	emit := func(e Event) {
+		e.Tick = next.CurrentTick
+		events = append(events, e)
+	}
+
+	for i := range next.Players {
+		p := &next.Players[i]
+		if p.Health <= 0 {
+			continue
+		}
+		switch action := actions[p.ID]; action {
+		case bombahead.PlaceBomb:
+			if bombAt(next, p.Pos) < 0 && bombsOwnedBy(next, p.ID) < s.maxBombs(p) {
+				next.Bombs = append(next.Bombs, bombahead.Bomb{Pos: p.Pos, Fuse: s.rules.BombFuse, Owner: p.ID, Range: s.bombRange(p)})
+				emit(Event{Kind: EventBombPlaced, Player: p.ID, Pos: p.Pos})
+			}
+		case bombahead.MoveUp, bombahead.MoveRight, bombahead.MoveDown, bombahead.MoveLeft:
+			target := move(p.Pos, action)
+			if next.Field.CellAt(target) == bombahead.Air && bombAt(next, target) < 0 {
+				p.Pos = target
+			}
+		}
+	}
+
+	var queue []int
+	for i := range next.Bombs {
+		next.Bombs[i].Fuse--
+		if next.Bombs[i].Fuse <= 0 {
+			queue = append(queue, i)
+		}
+	}
+	if len(queue) > 0 {
+		s.detonate(next, queue, emit)
+	}
+
+	updateViews(next, state)
+	return next, events
+}
+
+// detonate explodes the queued bombs and every bomb their blasts reach
+// Each blast cell is credited to the first bomb that reaches it
+func (s *Simulator) detonate(state *bombahead.GameState, queue []int, emit func(Event)) {
+	exploded := make([]bool, len(state.Bombs))
+	for _, i := range queue {
+		exploded[i] = true
+	}
+
+	owners := make(map[bombahead.Position]string)
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+
+		bomb := state.Bombs[i]
+		emit(Event{Kind: EventBombExploded, Player: bomb.Owner, Pos: bomb.Pos})
+		for _, cell := range bombahead.BlastCells(state.Field, bomb.Pos, cmp.Or(bomb.Range, s.rules.BombRange)) {
+			if _, ok := owners[cell]; !ok {
+				owners[cell] = bomb.Owner
+				state.Explosions = append(state.Explosions, cell)
+			}
+			if j := bombAt(state, cell); j >= 0 && !exploded[j] {
+				exploded[j] = true
+				queue = append(queue, j)
+			}
+		}
+	}
+
+	remaining := state.Bombs[:0]
+	for i, b := range state.Bombs {
+		if !exploded[i] {
+			remaining = append(remaining, b)
+		}
+	}
+	state.Bombs = remaining
+
+	for _, cell := range state.Explosions {
+		if state.Field.CellAt(cell) != bombahead.Box {
+			continue
+		}
+		state.Field.Cells[cell.Y*state.Field.Width+cell.X] = bombahead.Air
+		owner := owners[cell]
+		addScore(state, owner, s.rules.BoxScore)
+		emit(Event{Kind: EventBoxDestroyed, Player: owner, Pos: cell})
+	}
+
+	for i := range state.Players {
+		p := &state.Players[i]
+		owner, hit := owners[p.Pos]
+		if !hit || p.Health <= 0 {
+			continue
+		}
+		p.Health--
+		if owner != p.ID {
+			addScore(state, owner, s.rules.HitScore)
+		}
+		emit(Event{Kind: EventPlayerHit, Player: owner, Target: p.ID, Pos: p.Pos})
+		if p.Health <= 0 {
+			emit(Event{Kind: EventPlayerEliminated, Player: owner, Target: p.ID, Pos: p.Pos})
+		}
+	}
+}
+
+// Done reports whether the game is over: at most one player is left or MaxTicks is reached
+func (s *Simulator) Done(state *bombahead.GameState) bool {
+	if s.rules.MaxTicks > 0 && state.CurrentTick >= s.rules.MaxTicks {
+		return true
+	}
+	return Alive(state) <= 1
+}
+
+// Alive returns the number of players with health left
+func Alive(state *bombahead.GameState) int {
+	n := 0
+	for _, p := range state.Players {
+		if p.Health > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// Winner returns the last player standing, or the best scorer among the survivors
+// It returns "" on a tie or when nobody survived
+func Winner(state *bombahead.GameState) string {
+	best, bestScore, tie := "", -1, false
+	for _, p := range state.Players {
+		if p.Health <= 0 {
+			continue
+		}
+		switch {
+		case p.Score > bestScore:
+			best, bestScore, tie = p.ID, p.Score, false
+		case p.Score == bestScore:
+			tie = true
+		}
+	}
+	if tie {
+		return ""
+	}
+	return best
+}
+
+// Eliminate sets the health of a player to zero, e.g. when it disconnects
+func Eliminate(state *bombahead.GameState, id string) {
+	for i := range state.Players {
+		if state.Players[i].ID == id {
+			state.Players[i].Health = 0
+		}
+	}
+	updateViews(state, state)
+}
+
+// clone returns a deep copy of state
+func clone(state *bombahead.GameState) *bombahead.GameState {
+	c := *state
+	c.Field.Cells = append([]bombahead.CellType(nil), state.Field.Cells...)
+	c.Players = append([]bombahead.Player(nil), state.Players...)
+	c.Opponents = nil
+	c.Me = nil
+	c.Bombs = append([]bombahead.Bomb(nil), state.Bombs...)
+	c.Explosions = append([]bombahead.Position(nil), state.Explosions...)
+	c.Items = append([]bombahead.Item(nil), state.Items...)
+	return &c
+}
+
+// bombRange is the range of p's bombs, falling back to Rules for players without one
+func (s *Simulator) bombRange(p *bombahead.Player) int {
+	return cmp.Or(p.BombRange, s.rules.BombRange)
+}
+
+// maxBombs is the bomb capacity of p, falling back to Rules for players without one
+func (s *Simulator) maxBombs(p *bombahead.Player) int {
+	return cmp.Or(p.MaxBombs, s.rules.MaxBombs)
+}
+
+// updateViews keeps Me and Opponents of next pointing at the same player as in prev
+func updateViews(next, prev *bombahead.GameState) {
+	if prev.Me == nil {
+		next.Me = nil
+		next.Opponents = nil
+		return
+	}
+	id := prev.Me.ID
+	next.Me = nil
+	next.Opponents = next.Opponents[:0]
+	for _, p := range next.Players {
+		if p.ID == id {
+			me := p
+			next.Me = &me
+			continue
+		}
+		next.Opponents = append(next.Opponents, p)
+	}
+}
+
+func bombAt(state *bombahead.GameState, pos bombahead.Position) int {
+	for i, b := range state.Bombs {
+		if b.Pos == pos {
+			return i
+		}
+	}
+	return -1
+}
+
+func bombsOwnedBy(state *bombahead.GameState, id string) int {
+	n := 0
+	for _, b := range state.Bombs {
+		if b.Owner == id {
+			n++
+		}
+	}
+	return n
+}
+
+func addScore(state *bombahead.GameState, id string, points int) {
+	for i := range state.Players {
+		if state.Players[i].ID == id {
+			state.Players[i].Score += points
+			return
+		}
+	}
+}
+
+func move(pos bombahead.Position, action bombahead.Action) bombahead.Position {
+	switch action {
+	case bombahead.MoveUp:
+		pos.Y--
+	case bombahead.MoveDown:
+		pos.Y++
+	case bombahead.MoveLeft:
+		pos.X--
+	case bombahead.MoveRight:
+		pos.X++
+	}
+	return pos
+}
@@ -0,0 +1,201 @@
+package sim
+
+import (
+	"reflect"
+	"testing"
+
+	bombahead "github.com/N3moAhead/bombahead-go"
+)
+
+func testRules() Rules {
+	rules := DefaultRules()
+	rules.Width, rules.Height = 7, 7
+	rules.BoxDensity = 0
+	rules.BombFuse = 2
+	return rules
+}
+
+func TestNewGame_LayoutAndSpawns(t *testing.T) {
+	t.Parallel()
+
+	rules := testRules()
+	rules.BoxDensity = 1
+	state := New(rules, 1).NewGame([]string{"a", "b"})
+
+	field := state.Field
+	if field.CellAt(bombahead.Position{X: 0, Y: 3}) != bombahead.Wall || field.CellAt(bombahead.Position{X: 2, Y: 2}) != bombahead.Wall {
+		t.Fatal("expected border and pillar walls")
+	}
+	for _, p := range state.Players {
+		if field.CellAt(p.Pos) != bombahead.Air {
+			t.Fatalf("spawn of %s at %+v is not free", p.ID, p.Pos)
+		}
+	}
+	if state.Players[1].Pos != (bombahead.Position{X: 5, Y: 5}) {
+		t.Fatalf("second spawn = %+v, want opposite corner", state.Players[1].Pos)
+	}
+}
+
+func TestNewGame_DeterministicPerSeed(t *testing.T) {
+	t.Parallel()
+
+	rules := DefaultRules()
+	a := New(rules, 42).NewGame([]string{"a", "b"})
+	b := New(rules, 42).NewGame([]string{"a", "b"})
+	c := New(rules, 43).NewGame([]string{"a", "b"})
+
+	if !reflect.DeepEqual(a, b) {
+		t.Fatal("same seed produced different boards")
+	}
+	if reflect.DeepEqual(a.Field.Cells, c.Field.Cells) {
+		t.Fatal("different seeds produced the same board")
+	}
+}
+
+func TestStep_DoesNotModifyInput(t *testing.T) {
+	t.Parallel()
+
+	s := New(testRules(), 1)
+	state := s.NewGame([]string{"a", "b"})
+	before := clone(state)
+
+	next, _ := s.Step(state, map[string]bombahead.Action{"a": bombahead.PlaceBomb, "b": bombahead.MoveUp})
+	if !reflect.DeepEqual(state, before) {
+		t.Fatal("Step() modified its input state")
+	}
+	if next.CurrentTick != 1 || len(next.Bombs) != 1 || next.Bombs[0].Owner != "a" {
+		t.Fatalf("next = tick %d bombs %+v, want tick 1 with a's bomb", next.CurrentTick, next.Bombs)
+	}
+}
+
+func TestStep_BombChainDestroysBoxAndHitsPlayer(t *testing.T) {
+	t.Parallel()
+
+	s := New(testRules(), 1)
+	state := s.NewGame([]string{"a", "b"})
+	state.Field.Cells[1*7+4] = bombahead.Box
+	state.Players[0].Pos = bombahead.Position{X: 1, Y: 1}
+	state.Players[1].Pos = bombahead.Position{X: 3, Y: 3}
+
+	state, events := s.Step(state, map[string]bombahead.Action{"a": bombahead.PlaceBomb})
+	if len(events.Filter(EventBombPlaced)) != 1 || len(state.Bombs) != 1 || state.Bombs[0].Fuse != 1 {
+		t.Fatalf("bombs = %+v events = %+v, want one placed bomb with fuse 1", state.Bombs, events)
+	}
+
+	// A second bomb of a sits in the first blast and must chain
+	state.Bombs = append(state.Bombs, bombahead.Bomb{Pos: bombahead.Position{X: 3, Y: 1}, Fuse: 9, Owner: "a"})
+	state, events = s.Step(state, map[string]bombahead.Action{"a": bombahead.MoveDown, "b": bombahead.MoveUp})
+
+	if len(state.Bombs) != 0 || len(events.Filter(EventBombExploded)) != 2 {
+		t.Fatalf("bombs after chain = %+v, events = %+v, want both exploded", state.Bombs, events)
+	}
+	if state.Field.CellAt(bombahead.Position{X: 4, Y: 1}) != bombahead.Air || len(events.Filter(EventBoxDestroyed)) != 1 {
+		t.Fatal("expected chained blast to destroy the box")
+	}
+	if state.Players[0].Health != 2 {
+		t.Fatalf("player a health = %d, want 2 after standing in own blast", state.Players[0].Health)
+	}
+	if state.Players[1].Health != 2 || state.Players[1].Pos != (bombahead.Position{X: 3, Y: 2}) {
+		t.Fatalf("player b = %+v, want hit by chained blast at (3,2)", state.Players[1])
+	}
+	if state.Players[0].Score != 6 {
+		t.Fatalf("player a score = %d, want 6 for the box and hitting b", state.Players[0].Score)
+	}
+	if hits := events.Filter(EventPlayerHit); len(hits) != 2 {
+		t.Fatalf("hits = %+v, want a and b", hits)
+	}
+}
+
+func TestStep_UsesConfiguredRange(t *testing.T) {
+	t.Parallel()
+
+	rules := testRules()
+	rules.BombRange = 4
+	rules.BombFuse = 1
+	s := New(rules, 1)
+	state := s.NewGame([]string{"a", "b"})
+	state.Players[1].Pos = bombahead.Position{X: 5, Y: 1}
+
+	next, _ := s.Step(state, map[string]bombahead.Action{"a": bombahead.PlaceBomb})
+	if next.Players[1].Health != rules.StartHealth-1 {
+		t.Fatalf("player b health = %d, want hit by range-4 blast", next.Players[1].Health)
+	}
+	if len(next.Explosions) == 0 {
+		t.Fatal("expected explosions in next state")
+	}
+}
+
+func TestStep_UsesPlayerRangeAndCapacity(t *testing.T) {
+	t.Parallel()
+
+	rules := testRules()
+	rules.BombFuse = 1
+	s := New(rules, 1)
+	state := s.NewGame([]string{"a", "b"})
+	state.Players[0].BombRange = 4
+	state.Players[1].Pos = bombahead.Position{X: 5, Y: 1}
+
+	next, _ := s.Step(state, map[string]bombahead.Action{"a": bombahead.PlaceBomb})
+	if next.Players[1].Health != rules.StartHealth-1 {
+		t.Fatalf("player b health = %d, want hit by a's range-4 blast", next.Players[1].Health)
+	}
+
+	rules.BombFuse = 5
+	s = New(rules, 1)
+	state = s.NewGame([]string{"a", "b"})
+	state.Players[0].MaxBombs = 2
+	state, _ = s.Step(state, map[string]bombahead.Action{"a": bombahead.PlaceBomb})
+	state, _ = s.Step(state, map[string]bombahead.Action{"a": bombahead.MoveRight})
+	state, _ = s.Step(state, map[string]bombahead.Action{"a": bombahead.PlaceBomb})
+	if len(state.Bombs) != 2 {
+		t.Fatalf("len(Bombs) = %d, want 2 with MaxBombs 2", len(state.Bombs))
+	}
+	if state.Bombs[0].Range != rules.BombRange {
+		t.Fatalf("Bombs[0].Range = %d, want %d", state.Bombs[0].Range, rules.BombRange)
+	}
+}
+
+func TestStep_KeepsPerspective(t *testing.T) {
+	t.Parallel()
+
+	s := New(testRules(), 1)
+	state := s.NewGame([]string{"a", "b"})
+	me := state.Players[1]
+	state.Me = &me
+	state.Opponents = []bombahead.Player{state.Players[0]}
+
+	next, _ := s.Step(state, map[string]bombahead.Action{"b": bombahead.MoveUp})
+	if next.Me == nil || next.Me.ID != "b" || next.Me.Pos != (bombahead.Position{X: 5, Y: 4}) {
+		t.Fatalf("Me = %+v, want b after moving up", next.Me)
+	}
+	if len(next.Opponents) != 1 || next.Opponents[0].ID != "a" {
+		t.Fatalf("Opponents = %+v, want [a]", next.Opponents)
+	}
+}
+
+func TestDoneAndWinner(t *testing.T) {
+	t.Parallel()
+
+	s := New(testRules(), 1)
+	state := s.NewGame([]string{"a", "b", "c"})
+	if s.Done(state) {
+		t.Fatal("fresh game reported done")
+	}
+
+	Eliminate(state, "a")
+	state.Players[1].Score = 3
+	state.Players[2].Score = 3
+	if got := Winner(state); got != "" {
+		t.Fatalf("Winner() = %q, want tie", got)
+	}
+
+	state.Players[2].Score = 4
+	if got := Winner(state); got != "c" {
+		t.Fatalf("Winner() = %q, want c", got)
+	}
+
+	Eliminate(state, "b")
+	if !s.Done(state) || Alive(state) != 1 {
+		t.Fatal("expected game with one survivor to be done")
+	}
+}
@@ -0,0 +1,116 @@
+package tournament
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"text/tabwriter"
+)
+
+// Standing is the record of one entrant
+type Standing struct {
+	Name   string
+	Rating float64
+	Games  int
+	Wins   int
+	Draws  int
+	Losses int
+	// Byes counts Swiss rounds the entrant sat out; they do not count as games
+	Byes int
+}
+
+func (s *Standing) addResult(score float64) {
+	s.Games++
+	switch score {
+	case 1:
+		s.Wins++
+	case 0:
+		s.Losses++
+	default:
+		s.Draws++
+	}
+}
+
+// Points returns wins plus half the draws, with a full point per bye
+func (s Standing) Points() float64 {
+	return float64(s.Wins) + float64(s.Draws)/2 + float64(s.Byes)
+}
+
+// ScoreRate returns the share of points won per game played, counting draws as half
+func (s Standing) ScoreRate() float64 {
+	if s.Games == 0 {
+		return 0
+	}
+	return (float64(s.Wins) + float64(s.Draws)/2) / float64(s.Games)
+}
+
+// WinRate returns the share of games won
+func (s Standing) WinRate() float64 {
+	if s.Games == 0 {
+		return 0
+	}
+	return float64(s.Wins) / float64(s.Games)
+}
+
+// ConfidenceInterval returns the 95% Wilson interval of the score rate
+func (s Standing) ConfidenceInterval() (low, high float64) {
+	return wilson(s.ScoreRate(), s.Games, 1.96)
+}
+
+// Leaderboard lists standings, best first
+type Leaderboard []Standing
+
+func (t *table) leaderboard() Leaderboard {
+	board := append(Leaderboard(nil), t.standings...)
+	sort.SliceStable(board, func(i, j int) bool {
+		if board[i].Rating != board[j].Rating {
+			return board[i].Rating > board[j].Rating
+		}
+		return board[i].Name < board[j].Name
+	})
+	return board
+}
+
+// WriteTo prints the leaderboard as an aligned table
+func (l Leaderboard) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	tw := tabwriter.NewWriter(cw, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "#\tBot\tElo\tGames\tW\tD\tL\tWin%\tScore%\t95% CI\t")
+	for i, s := range l {
+		low, high := s.ConfidenceInterval()
+		fmt.Fprintf(tw, "%d\t%s\t%.0f\t%d\t%d\t%d\t%d\t%.1f\t%.1f\t%.1f-%.1f\t\n",
+			i+1, s.Name, s.Rating, s.Games, s.Wins, s.Draws, s.Losses,
+			100*s.WinRate(), 100*s.ScoreRate(), 100*low, 100*high)
+	}
+	err := tw.Flush()
+	return cw.n, err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// expectedScore is the Elo expectation of a player rated a against one rated b
+func expectedScore(a, b float64) float64 {
+	return 1 / (1 + math.Pow(10, (b-a)/400))
+}
+
+// wilson returns the Wilson score interval of proportion p over n trials
+func wilson(p float64, n int, z float64) (low, high float64) {
+	if n == 0 {
+		return 0, 1
+	}
+	nf := float64(n)
+	denom := 1 + z*z/nf
+	center := (p + z*z/(2*nf)) / denom
+	margin := z * math.Sqrt(p*(1-p)/nf+z*z/(4*nf*nf)) / denom
+	return math.Max(0, center-margin), math.Min(1, center+margin)
+}
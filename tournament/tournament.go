@@ -0,0 +1,302 @@
+// Package tournament plays many headless matches between bots and ranks them
+//
+// Pairings are played with sim.RunMatch, either as a full round robin or as a Swiss
+// system, over many board seeds and in parallel. Each pairing plays every seed twice with
+// swapped spawns, so neither bot profits from a better corner.
+package tournament
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+
+	bombahead "github.com/N3moAhead/bombahead-go"
+	"github.com/N3moAhead/bombahead-go/sim"
+)
+
+// Entrant is a bot taking part in a tournament
+// New is called for every game, so bots never carry memory from one game into the next
+type Entrant struct {
+	Name string
+	New  func() bombahead.Bot
+}
+
+var (
+	registryMu sync.Mutex
+	registry   []Entrant
+)
+
+// Register adds a bot to the registry used by Registered
+// Registering the same name twice panics
+func Register(name string, newBot func() bombahead.Bot) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	for _, e := range registry {
+		if e.Name == name {
+			panic(fmt.Sprintf("tournament: bot %q registered twice", name))
+		}
+	}
+	registry = append(registry, Entrant{Name: name, New: newBot})
+}
+
+// Registered returns the registered bots in registration order
+func Registered() []Entrant {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	return append([]Entrant(nil), registry...)
+}
+
+// Format selects how pairings are chosen
+type Format string
+
+const (
+	// RoundRobin pairs every bot with every other bot
+	RoundRobin Format = "round-robin"
+	// Swiss pairs bots with similar points for a number of rounds
+	Swiss Format = "swiss"
+)
+
+// Config configures Run
+type Config struct {
+	Format Format
+	// Seeds is the number of boards each pairing plays, each one twice with swapped spawns
+	Seeds int
+	// Rounds is the number of Swiss rounds; it defaults to ceil(log2(bots)) + 1
+	Rounds int
+	// Parallel is the number of games played at once; it defaults to runtime.NumCPU()
+	Parallel int
+	// BaseSeed is added to the seed index to select boards
+	BaseSeed uint64
+	Rules    sim.Rules
+	// InitialRating and KFactor configure the Elo ratings
+	InitialRating float64
+	KFactor       float64
+}
+
+func (c Config) withDefaults(entrants int) Config {
+	if c.Format == "" {
+		c.Format = RoundRobin
+	}
+	if c.Seeds <= 0 {
+		c.Seeds = 10
+	}
+	if c.Rounds <= 0 {
+		c.Rounds = 1
+		for n := 1; n < entrants; n *= 2 {
+			c.Rounds++
+		}
+	}
+	if c.Parallel <= 0 {
+		c.Parallel = runtime.NumCPU()
+	}
+	if c.Rules == (sim.Rules{}) {
+		c.Rules = sim.DefaultRules()
+	}
+	if c.InitialRating == 0 {
+		c.InitialRating = 1500
+	}
+	if c.KFactor == 0 {
+		c.KFactor = 16
+	}
+	return c
+}
+
+// game is one scheduled match between two entrants
+type game struct {
+	a, b int
+	seed uint64
+	// swapped seats b at the first spawn
+	swapped bool
+}
+
+// outcome is the result of a game from the perspective of entrant a
+// 1 is a win, 0.5 a draw and 0 a loss
+type outcome struct {
+	game  game
+	score float64
+}
+
+// Run plays the tournament and returns the final leaderboard, best entrant first
+func Run(entrants []Entrant, cfg Config) (Leaderboard, error) {
+	if len(entrants) < 2 {
+		return nil, errors.New("a tournament needs at least two bots")
+	}
+	cfg = cfg.withDefaults(len(entrants))
+
+	t := newTable(entrants, cfg)
+	switch cfg.Format {
+	case RoundRobin:
+		var games []game
+		for a := 0; a < len(entrants); a++ {
+			for b := a + 1; b < len(entrants); b++ {
+				games = append(games, pairingGames(a, b, cfg)...)
+			}
+		}
+		t.record(play(entrants, games, cfg))
+
+	case Swiss:
+		played := make(map[[2]int]bool)
+		for round := 0; round < cfg.Rounds; round++ {
+			pairs, bye := t.swissPairs(played)
+			if bye >= 0 {
+				t.standings[bye].Byes++
+			}
+			var games []game
+			for _, p := range pairs {
+				played[p] = true
+				games = append(games, pairingGames(p[0], p[1], cfg)...)
+			}
+			t.record(play(entrants, games, cfg))
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown tournament format %q", cfg.Format)
+	}
+
+	return t.leaderboard(), nil
+}
+
+// pairingGames schedules every seed twice, once with each bot on the first spawn
+func pairingGames(a, b int, cfg Config) []game {
+	games := make([]game, 0, 2*cfg.Seeds)
+	for i := 0; i < cfg.Seeds; i++ {
+		seed := cfg.BaseSeed + uint64(i)
+		games = append(games, game{a: a, b: b, seed: seed}, game{a: a, b: b, seed: seed, swapped: true})
+	}
+	return games
+}
+
+// play runs games on cfg.Parallel workers and returns the outcomes in schedule order
+func play(entrants []Entrant, games []game, cfg Config) []outcome {
+	outcomes := make([]outcome, len(games))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < cfg.Parallel; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				outcomes[i] = playGame(entrants, games[i], cfg.Rules)
+			}
+		}()
+	}
+	for i := range games {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return outcomes
+}
+
+func playGame(entrants []Entrant, g game, rules sim.Rules) outcome {
+	first, second := g.a, g.b
+	if g.swapped {
+		first, second = g.b, g.a
+	}
+	bots := []bombahead.Bot{entrants[first].New(), entrants[second].New()}
+	result := sim.RunMatch(bots, sim.MatchConfig{Rules: rules, Seed: g.seed})
+
+	o := outcome{game: g, score: 0.5}
+	switch {
+	case result.WinnerIndex < 0:
+	case (result.WinnerIndex == 0) != g.swapped:
+		o.score = 1
+	default:
+		o.score = 0
+	}
+	return o
+}
+
+// table accumulates results while the tournament runs
+type table struct {
+	cfg       Config
+	standings []Standing
+}
+
+func newTable(entrants []Entrant, cfg Config) *table {
+	t := &table{cfg: cfg, standings: make([]Standing, len(entrants))}
+	for i, e := range entrants {
+		t.standings[i] = Standing{Name: e.Name, Rating: cfg.InitialRating}
+	}
+	return t
+}
+
+// record applies outcomes in schedule order, so ratings do not depend on scheduling
+func (t *table) record(outcomes []outcome) {
+	for _, o := range outcomes {
+		a, b := &t.standings[o.game.a], &t.standings[o.game.b]
+		a.addResult(o.score)
+		b.addResult(1 - o.score)
+
+		expected := expectedScore(a.Rating, b.Rating)
+		delta := t.cfg.KFactor * (o.score - expected)
+		a.Rating += delta
+		b.Rating -= delta
+	}
+}
+
+// swissPairs pairs entrants with similar points, avoiding rematches where possible
+// With an odd number of entrants the lowest ranked entrant without a bye sits out
+func (t *table) swissPairs(played map[[2]int]bool) (pairs [][2]int, bye int) {
+	order := make([]int, len(t.standings))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := t.standings[order[i]], t.standings[order[j]]
+		if a.Points() != b.Points() {
+			return a.Points() > b.Points()
+		}
+		return a.Rating > b.Rating
+	})
+
+	bye = -1
+	if len(order)%2 == 1 {
+		pick := len(order) - 1
+		for i := len(order) - 1; i >= 0; i-- {
+			if t.standings[order[i]].Byes == 0 {
+				pick = i
+				break
+			}
+		}
+		bye = order[pick]
+		order = append(order[:pick], order[pick+1:]...)
+	}
+
+	used := make([]bool, len(order))
+	for i := range order {
+		if used[i] {
+			continue
+		}
+		used[i] = true
+		partner := -1
+		for j := i + 1; j < len(order); j++ {
+			if used[j] {
+				continue
+			}
+			if partner < 0 {
+				partner = j
+			}
+			if !played[pairKey(order[i], order[j])] {
+				partner = j
+				break
+			}
+		}
+		if partner < 0 {
+			continue
+		}
+		used[partner] = true
+		pairs = append(pairs, pairKey(order[i], order[partner]))
+	}
+	return pairs, bye
+}
+
+func pairKey(a, b int) [2]int {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]int{a, b}
+}
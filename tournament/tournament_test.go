@@ -0,0 +1,213 @@
+package tournament
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	bombahead "github.com/N3moAhead/bombahead-go"
+	"github.com/N3moAhead/bombahead-go/sim"
+)
+
+type idleBot struct{}
+
+func (idleBot) GetNextMove(*bombahead.GameState, *bombahead.GameHelpers) bombahead.Action {
+	return bombahead.DoNothing
+}
+
+// suicideBot sits on its own bomb
+type suicideBot struct{}
+
+func (suicideBot) GetNextMove(*bombahead.GameState, *bombahead.GameHelpers) bombahead.Action {
+	return bombahead.PlaceBomb
+}
+
+func testEntrants() []Entrant {
+	return []Entrant{
+		{Name: "suicide", New: func() bombahead.Bot { return suicideBot{} }},
+		{Name: "idle", New: func() bombahead.Bot { return idleBot{} }},
+		{Name: "idle2", New: func() bombahead.Bot { return idleBot{} }},
+	}
+}
+
+func testConfig(format Format) Config {
+	rules := sim.DefaultRules()
+	rules.Width, rules.Height = 7, 7
+	rules.MaxTicks = 30
+	return Config{Format: format, Seeds: 3, Rules: rules}
+}
+
+func byName(board Leaderboard) map[string]Standing {
+	m := make(map[string]Standing)
+	for _, s := range board {
+		m[s.Name] = s
+	}
+	return m
+}
+
+func TestRun_RoundRobin(t *testing.T) {
+	t.Parallel()
+
+	board, err := Run(testEntrants(), testConfig(RoundRobin))
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if board[len(board)-1].Name != "suicide" {
+		t.Fatalf("last place = %q, want suicide", board[len(board)-1].Name)
+	}
+	s := byName(board)
+	// Two opponents, three seeds, two spawns each
+	if s["suicide"].Games != 12 || s["suicide"].Losses != 12 {
+		t.Fatalf("suicide = %+v, want 12 games lost", s["suicide"])
+	}
+	if s["idle"].Wins != 6 || s["idle"].Draws != 6 {
+		t.Fatalf("idle = %+v, want 6 wins and 6 draws", s["idle"])
+	}
+	if s["idle"].Rating <= 1500 || s["suicide"].Rating >= 1500 {
+		t.Fatalf("ratings idle=%v suicide=%v, want idle above and suicide below 1500", s["idle"].Rating, s["suicide"].Rating)
+	}
+}
+
+func TestRun_DeterministicAcrossParallelism(t *testing.T) {
+	t.Parallel()
+
+	serial := testConfig(RoundRobin)
+	serial.Parallel = 1
+	parallel := testConfig(RoundRobin)
+	parallel.Parallel = 8
+
+	a, err := Run(testEntrants(), serial)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	b, err := Run(testEntrants(), parallel)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if !reflect.DeepEqual(a, b) {
+		t.Fatalf("serial %+v != parallel %+v", a, b)
+	}
+}
+
+func TestRun_SwissGivesByes(t *testing.T) {
+	t.Parallel()
+
+	cfg := testConfig(Swiss)
+	cfg.Rounds = 3
+	board, err := Run(testEntrants(), cfg)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	byes := 0
+	for _, s := range board {
+		byes += s.Byes
+		if s.Byes > 1 {
+			t.Fatalf("%s had %d byes, want at most 1", s.Name, s.Byes)
+		}
+	}
+	if byes != 3 {
+		t.Fatalf("total byes = %d, want 3", byes)
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	t.Parallel()
+
+	if _, err := Run(testEntrants()[:1], Config{}); err == nil {
+		t.Fatalf("Run() with one bot error = nil")
+	}
+	if _, err := Run(testEntrants(), Config{Format: "knockout"}); err == nil {
+		t.Fatalf("Run() with unknown format error = nil")
+	}
+}
+
+func TestSwissPairs_AvoidsRematches(t *testing.T) {
+	t.Parallel()
+
+	tbl := newTable(make([]Entrant, 4), testConfig(Swiss))
+	played := map[[2]int]bool{{0, 1}: true}
+
+	pairs, bye := tbl.swissPairs(played)
+	if bye != -1 {
+		t.Fatalf("bye = %d, want none", bye)
+	}
+	want := [][2]int{{0, 2}, {1, 3}}
+	if !reflect.DeepEqual(pairs, want) {
+		t.Fatalf("swissPairs() = %v, want %v", pairs, want)
+	}
+}
+
+func TestWilson(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		p         float64
+		n         int
+		low, high float64
+	}{
+		{0.5, 100, 0.4038, 0.5962},
+		{1, 10, 0.7225, 1},
+		{0, 0, 0, 1},
+	}
+	for _, tt := range tests {
+		low, high := wilson(tt.p, tt.n, 1.96)
+		if math.Abs(low-tt.low) > 1e-3 || math.Abs(high-tt.high) > 1e-3 {
+			t.Fatalf("wilson(%v, %d) = %.4f-%.4f, want %.4f-%.4f", tt.p, tt.n, low, high, tt.low, tt.high)
+		}
+	}
+}
+
+func TestExpectedScore(t *testing.T) {
+	t.Parallel()
+
+	if got := expectedScore(1500, 1500); got != 0.5 {
+		t.Fatalf("expectedScore(equal) = %v, want 0.5", got)
+	}
+	if got := expectedScore(1900, 1500); math.Abs(got-0.909) > 1e-3 {
+		t.Fatalf("expectedScore(+400) = %v, want 0.909", got)
+	}
+}
+
+// registerRuns makes the names of TestRegister unique, as the registry outlives a run with -count
+var registerRuns atomic.Int64
+
+func TestRegister(t *testing.T) {
+	t.Parallel()
+
+	name := fmt.Sprintf("register-test-%d", registerRuns.Add(1))
+	Register(name, func() bombahead.Bot { return idleBot{} })
+	found := false
+	for _, e := range Registered() {
+		found = found || e.Name == name
+	}
+	if !found {
+		t.Fatalf("Registered() does not contain %s", name)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("Register() twice did not panic")
+		}
+	}()
+	Register(name, func() bombahead.Bot { return idleBot{} })
+}
+
+func TestLeaderboard_WriteTo(t *testing.T) {
+	t.Parallel()
+
+	board := Leaderboard{{Name: "alpha", Rating: 1510, Games: 4, Wins: 3, Losses: 1}}
+	var buf bytes.Buffer
+	n, err := board.WriteTo(&buf)
+	if err != nil || n != int64(buf.Len()) {
+		t.Fatalf("WriteTo() = %d, %v, want %d, nil", n, err, buf.Len())
+	}
+	if out := buf.String(); !strings.Contains(out, "alpha") || !strings.Contains(out, "75.0") {
+		t.Fatalf("WriteTo() output missing row:\n%s", out)
+	}
+}
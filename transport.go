@@ -0,0 +1,142 @@
+package bombahead
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/N3moAhead/bombahead-go/internal/network"
+)
+
+// Message is the envelope exchanged with the server
+type Message = network.Message
+
+// Transport carries protocol messages between the client loop and a server
+// ReadMessage should return io.EOF once the other side has closed the transport
+type Transport interface {
+	ReadMessage() (*Message, error)
+	Send(msgType string, payload any) error
+	Close() error
+}
+
+// Dialer opens a Transport and is called once per connection attempt
+type Dialer func(ctx context.Context, url, token string) (Transport, error)
+
+// WebSocketDialer connects to url over WebSocket; it is the default Dialer
+func WebSocketDialer(ctx context.Context, url, token string) (Transport, error) {
+	client, err := network.ConnectContext(ctx, url, token)
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// WithDialer replaces WebSocketDialer, e.g. to drive the bot from a simulator
+func WithDialer(dial Dialer) Option {
+	return func(c *config) {
+		if dial != nil {
+			c.dial = dial
+		}
+	}
+}
+
+// WithTransport runs the client on an already open Transport
+// The transport can only be used once, so reconnecting after it closes fails with ErrDial
+func WithTransport(t Transport) Option {
+	var once sync.Once
+	return WithDialer(func(context.Context, string, string) (Transport, error) {
+		var used Transport
+		once.Do(func() { used = t })
+		if used == nil {
+			return nil, errors.New("transport already used")
+		}
+		return used, nil
+	})
+}
+
+const pipeBufferSize = 64
+
+// PipeTransport is one end of an in-memory Transport created by NewPipe
+type PipeTransport struct {
+	in  <-chan *Message
+	out chan<- *Message
+
+	closed     chan struct{}
+	peerClosed <-chan struct{}
+	closeOnce  sync.Once
+}
+
+// NewPipe returns two connected in-memory transports
+// Messages sent on one end are read from the other; each direction buffers a few messages
+// before Send blocks. Use one end as the client transport and drive the other as the server
+func NewPipe() (client, server *PipeTransport) {
+	toServer := make(chan *Message, pipeBufferSize)
+	toClient := make(chan *Message, pipeBufferSize)
+	clientClosed := make(chan struct{})
+	serverClosed := make(chan struct{})
+
+	client = &PipeTransport{in: toClient, out: toServer, closed: clientClosed, peerClosed: serverClosed}
+	server = &PipeTransport{in: toServer, out: toClient, closed: serverClosed, peerClosed: clientClosed}
+	return client, server
+}
+
+// ReadMessage returns the next message sent by the other end
+// It returns io.EOF once the other end is closed and all buffered messages are read,
+// and io.ErrClosedPipe after this end has been closed
+func (p *PipeTransport) ReadMessage() (*Message, error) {
+	select {
+	case <-p.closed:
+		return nil, io.ErrClosedPipe
+	default:
+	}
+
+	select {
+	case msg := <-p.in:
+		return msg, nil
+	case <-p.closed:
+		return nil, io.ErrClosedPipe
+	case <-p.peerClosed:
+		// Drain messages sent before the peer closed
+		select {
+		case msg := <-p.in:
+			return msg, nil
+		default:
+			return nil, io.EOF
+		}
+	}
+}
+
+// Send encodes payload like the WebSocket transport and hands it to the other end
+func (p *PipeTransport) Send(msgType string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal payload: %w", err)
+	}
+	msg := &Message{Type: msgType, Payload: data}
+
+	select {
+	case <-p.closed:
+		return io.ErrClosedPipe
+	case <-p.peerClosed:
+		return io.ErrClosedPipe
+	default:
+	}
+
+	select {
+	case p.out <- msg:
+		return nil
+	case <-p.closed:
+		return io.ErrClosedPipe
+	case <-p.peerClosed:
+		return io.ErrClosedPipe
+	}
+}
+
+// Close closes this end; the other end reads io.EOF afterwards
+func (p *PipeTransport) Close() error {
+	p.closeOnce.Do(func() { close(p.closed) })
+	return nil
+}
@@ -0,0 +1,93 @@
+package bombahead
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestPipeTransport_SendReadAndClose(t *testing.T) {
+	t.Parallel()
+
+	client, server := NewPipe()
+	if err := client.Send("ping", map[string]int{"n": 1}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	msg, err := server.ReadMessage()
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+	var payload map[string]int
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil || msg.Type != "ping" || payload["n"] != 1 {
+		t.Fatalf("ReadMessage() = %s %s (%v), want ping {n:1}", msg.Type, msg.Payload, err)
+	}
+
+	if err := server.Send("pong", nil); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	server.Close()
+
+	if msg, err := client.ReadMessage(); err != nil || msg.Type != "pong" {
+		t.Fatalf("ReadMessage() after peer close = %v, %v, want buffered pong", msg, err)
+	}
+	if _, err := client.ReadMessage(); !errors.Is(err, io.EOF) {
+		t.Fatalf("ReadMessage() error = %v, want io.EOF", err)
+	}
+	if err := client.Send("late", nil); !errors.Is(err, io.ErrClosedPipe) {
+		t.Fatalf("Send() to closed peer error = %v, want io.ErrClosedPipe", err)
+	}
+	if _, err := server.ReadMessage(); !errors.Is(err, io.ErrClosedPipe) {
+		t.Fatalf("ReadMessage() on closed end error = %v, want io.ErrClosedPipe", err)
+	}
+}
+
+func TestRunContext_OverPipe(t *testing.T) {
+	t.Parallel()
+
+	client, server := NewPipe()
+	moves := make(chan Action, 1)
+	go func() {
+		defer server.Close()
+		if _, err := server.ReadMessage(); err != nil {
+			t.Errorf("server read ready: %v", err)
+			return
+		}
+		_ = server.Send(msgWelcome, welcomePayload{ClientID: "p1"})
+		_ = server.Send(msgClassicState, map[string]any{
+			"players": []Player{{ID: "p1"}},
+			"field":   map[string]any{"width": 1, "height": 1, "field": []string{"AIR"}},
+		})
+		msg, err := server.ReadMessage()
+		if err != nil {
+			t.Errorf("server read move: %v", err)
+			return
+		}
+		var input classicInputPayload
+		_ = json.Unmarshal(msg.Payload, &input)
+		moves <- input.Move
+	}()
+
+	err := RunContext(context.Background(), constantBot{action: MoveDown}, WithTransport(client))
+	if !errors.Is(err, ErrServerClosed) {
+		t.Fatalf("RunContext() error = %v, want ErrServerClosed", err)
+	}
+	if got := <-moves; got != MoveDown {
+		t.Fatalf("server received %q, want %q", got, MoveDown)
+	}
+}
+
+func TestWithTransport_SingleUse(t *testing.T) {
+	t.Parallel()
+
+	client, _ := NewPipe()
+	cfg := newConfig([]Option{WithTransport(client)})
+	if _, err := cfg.dial(context.Background(), "", ""); err != nil {
+		t.Fatalf("first dial error = %v", err)
+	}
+	if _, err := cfg.dial(context.Background(), "", ""); err == nil {
+		t.Fatal("second dial expected error, got nil")
+	}
+}
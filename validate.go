@@ -0,0 +1,163 @@
+package bombahead
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"strings"
+)
+
+// WithStrictProtocol rejects classic_state payloads that contain any Anomaly
+// Rejected states are logged and skipped, so the bot is not called for that tick.
+// Without this option anomalies are repaired as before and reported to ProtocolAnomalyListener
+func WithStrictProtocol() Option {
+	return func(c *config) {
+		c.strict = true
+	}
+}
+
+// AnomalyKind classifies a problem found in a classic_state payload
+type AnomalyKind string
+
+const (
+	// AnomalySizeMismatch means the field array does not hold width*height cells,
+	// or the size is negative or larger than maxFieldCells
+	AnomalySizeMismatch AnomalyKind = "size_mismatch"
+	// AnomalyUnknownCell means a cell uses a code other than AIR, WALL, BOX or 0-2
+	AnomalyUnknownCell AnomalyKind = "unknown_cell"
+	// AnomalyOutOfBounds means a bomb, explosion or player lies outside the field
+	AnomalyOutOfBounds AnomalyKind = "out_of_bounds"
+	// AnomalyDuplicatePlayer means two players share an ID
+	AnomalyDuplicatePlayer AnomalyKind = "duplicate_player"
+	// AnomalyUnknownSelf means the welcome client ID is not among the players
+	AnomalyUnknownSelf AnomalyKind = "unknown_self"
+)
+
+// Anomaly is one problem found in a classic_state payload
+type Anomaly struct {
+	Kind   AnomalyKind
+	Detail string
+}
+
+func (a Anomaly) String() string {
+	return string(a.Kind) + ": " + a.Detail
+}
+
+// ProtocolError is the parse error for a rejected state in strict mode
+// It matches ErrProtocol with errors.Is
+type ProtocolError struct {
+	Anomalies []Anomaly
+}
+
+func (e *ProtocolError) Error() string {
+	parts := make([]string, len(e.Anomalies))
+	for i, a := range e.Anomalies {
+		parts[i] = a.String()
+	}
+	return fmt.Sprintf("malformed classic state: %s", strings.Join(parts, "; "))
+}
+
+func (e *ProtocolError) Unwrap() error {
+	return ErrProtocol
+}
+
+// AnomalyReport is passed to ProtocolAnomalyListener for every accepted state with anomalies
+type AnomalyReport struct {
+	Tick      int
+	Anomalies []Anomaly
+	// Totals counts every anomaly kind seen since the client started
+	Totals map[AnomalyKind]int
+}
+
+// ProtocolAnomalyListener can be implemented by a Bot to observe anomalies in lenient mode
+type ProtocolAnomalyListener interface {
+	OnProtocolAnomalies(report AnomalyReport)
+}
+
+// maxFieldCells bounds width*height, so a corrupt size cannot make the client allocate without limit
+const maxFieldCells = 1 << 20
+
+// validFieldSize reports whether a field of w x h cells can be allocated
+func validFieldSize(w, h int) bool {
+	return w >= 0 && h >= 0 && (h == 0 || w <= maxFieldCells/h)
+}
+
+// validatePayload checks everything but the cell codes, which are checked while decoding
+func validatePayload(payload *classicStatePayload, myID string) []Anomaly {
+	var anomalies []Anomaly
+	add := func(kind AnomalyKind, format string, args ...any) {
+		anomalies = append(anomalies, Anomaly{Kind: kind, Detail: fmt.Sprintf(format, args...)})
+	}
+
+	w, h := payload.Field.Width, payload.Field.Height
+	if w < 0 || h < 0 {
+		add(AnomalySizeMismatch, "negative field size %dx%d", w, h)
+	} else if !validFieldSize(w, h) {
+		add(AnomalySizeMismatch, "field size %dx%d exceeds %d cells", w, h, maxFieldCells)
+	} else if got := len(payload.Field.Field); got != w*h {
+		add(AnomalySizeMismatch, "field has %d cells, want %dx%d=%d", got, w, h, w*h)
+	}
+
+	// Positions are only checked against a valid size, a broken size is reported once above
+	inBounds := func(pos Position) bool {
+		return !validFieldSize(w, h) || pos.X >= 0 && pos.X < w && pos.Y >= 0 && pos.Y < h
+	}
+	for i, b := range payload.Bombs {
+		if !inBounds(b.Pos) {
+			add(AnomalyOutOfBounds, "bomb %d at (%d,%d) outside %dx%d field", i, b.Pos.X, b.Pos.Y, w, h)
+		}
+	}
+	for i, e := range payload.Explosions {
+		if !inBounds(e) {
+			add(AnomalyOutOfBounds, "explosion %d at (%d,%d) outside %dx%d field", i, e.X, e.Y, w, h)
+		}
+	}
+	for i, it := range payload.Items {
+		if !inBounds(it.Pos) {
+			add(AnomalyOutOfBounds, "item %d at (%d,%d) outside %dx%d field", i, it.Pos.X, it.Pos.Y, w, h)
+		}
+	}
+
+	seen := make(map[string]bool, len(payload.Players))
+	foundSelf := false
+	for _, p := range payload.Players {
+		if seen[p.ID] {
+			add(AnomalyDuplicatePlayer, "player ID %q appears more than once", p.ID)
+		}
+		seen[p.ID] = true
+		if !inBounds(p.Pos) {
+			add(AnomalyOutOfBounds, "player %q at (%d,%d) outside %dx%d field", p.ID, p.Pos.X, p.Pos.Y, w, h)
+		}
+		if p.ID == myID {
+			foundSelf = true
+		}
+	}
+	if !foundSelf && len(payload.Players) > 0 {
+		if myID == "" {
+			add(AnomalyUnknownSelf, "no welcome received before the first state")
+		} else {
+			add(AnomalyUnknownSelf, "client ID %q is not among the players", myID)
+		}
+	}
+
+	return anomalies
+}
+
+// reportAnomalies logs anomalies of an accepted state and forwards them to the bot
+func (r *runner) reportAnomalies(tick int, anomalies []Anomaly) {
+	if r.anomalyTotals == nil {
+		r.anomalyTotals = make(map[AnomalyKind]int)
+	}
+	for _, a := range anomalies {
+		r.anomalyTotals[a.Kind]++
+		log.Printf("Protocol anomaly on tick %d: %s", tick, a)
+	}
+
+	if l, ok := r.bot.(ProtocolAnomalyListener); ok {
+		l.OnProtocolAnomalies(AnomalyReport{
+			Tick:      tick,
+			Anomalies: anomalies,
+			Totals:    maps.Clone(r.anomalyTotals),
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package bombahead
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestStateParser_StrictRejectsAnomalies(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		raw  string
+		want AnomalyKind
+	}{
+		{
+			name: "short field",
+			raw:  `{"players":[{"id":"p1"}],"field":{"width":2,"height":1,"field":["AIR"]}}`,
+			want: AnomalySizeMismatch,
+		},
+		{
+			name: "negative field size",
+			raw:  `{"players":[{"id":"p1"}],"field":{"width":-1,"height":3,"field":["AIR"]}}`,
+			want: AnomalySizeMismatch,
+		},
+		{
+			name: "oversized field",
+			raw:  `{"players":[{"id":"p1"}],"field":{"width":4611686018427387904,"height":4,"field":["AIR"]}}`,
+			want: AnomalySizeMismatch,
+		},
+		{
+			name: "unknown numeric cell",
+			raw:  `{"players":[{"id":"p1"}],"field":{"width":1,"height":1,"field":[7]}}`,
+			want: AnomalyUnknownCell,
+		},
+		{
+			name: "unknown string cell",
+			raw:  `{"players":[{"id":"p1"}],"field":{"width":1,"height":1,"field":["LAVA"]}}`,
+			want: AnomalyUnknownCell,
+		},
+		{
+			name: "bomb out of bounds",
+			raw:  `{"players":[{"id":"p1"}],"field":{"width":1,"height":1,"field":["AIR"]},"bombs":[{"pos":{"x":3,"y":0},"fuse":2}]}`,
+			want: AnomalyOutOfBounds,
+		},
+		{
+			name: "item out of bounds",
+			raw:  `{"players":[{"id":"p1"}],"field":{"width":1,"height":1,"field":["AIR"]},"items":[{"pos":{"x":0,"y":2},"kind":"SPEED"}]}`,
+			want: AnomalyOutOfBounds,
+		},
+		{
+			name: "duplicate player",
+			raw:  `{"players":[{"id":"p1"},{"id":"p1"}],"field":{"width":1,"height":1,"field":["AIR"]}}`,
+			want: AnomalyDuplicatePlayer,
+		},
+		{
+			name: "unknown self",
+			raw:  `{"players":[{"id":"other"}],"field":{"width":1,"height":1,"field":["AIR"]}}`,
+			want: AnomalyUnknownSelf,
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			strict := stateParser{strict: true}
+			state, _, err := strict.parse([]byte(tc.raw), "p1")
+			if state != nil {
+				t.Fatalf("parse() state = %+v, want nil in strict mode", state)
+			}
+			var protoErr *ProtocolError
+			if !errors.As(err, &protoErr) || !errors.Is(err, ErrProtocol) {
+				t.Fatalf("parse() error = %v, want *ProtocolError matching ErrProtocol", err)
+			}
+			if len(protoErr.Anomalies) != 1 || protoErr.Anomalies[0].Kind != tc.want {
+				t.Fatalf("anomalies = %v, want one %q", protoErr.Anomalies, tc.want)
+			}
+			if !strings.Contains(err.Error(), string(tc.want)) {
+				t.Fatalf("Error() = %q, want it to name %q", err.Error(), tc.want)
+			}
+
+			var lenient stateParser
+			state, anomalies, err := lenient.parse([]byte(tc.raw), "p1")
+			if err != nil || state == nil {
+				t.Fatalf("lenient parse() = %v, %v, want repaired state", state, err)
+			}
+			if len(anomalies) != 1 || anomalies[0].Kind != tc.want {
+				t.Fatalf("lenient anomalies = %v, want one %q", anomalies, tc.want)
+			}
+		})
+	}
+}
+
+func TestStateParser_StrictAcceptsValidState(t *testing.T) {
+	t.Parallel()
+
+	p := stateParser{strict: true}
+	raw := `{"players":[{"id":"p1"},{"id":"p2","pos":{"x":1,"y":0}}],"field":{"width":2,"height":1,"field":["AIR",2]},"bombs":[{"pos":{"x":0,"y":0},"fuse":2}]}`
+	state, anomalies, err := p.parse([]byte(raw), "p1")
+	if err != nil || len(anomalies) != 0 {
+		t.Fatalf("parse() = %v, %v, want no anomalies", anomalies, err)
+	}
+	if state.Me == nil || state.Me.ID != "p1" {
+		t.Fatalf("Me = %+v, want p1", state.Me)
+	}
+}
+
+type anomalyBot struct {
+	constantBot
+	reports []AnomalyReport
+}
+
+func (b *anomalyBot) OnProtocolAnomalies(report AnomalyReport) {
+	b.reports = append(b.reports, report)
+}
+
+func TestReportAnomalies_CountsTotals(t *testing.T) {
+	t.Parallel()
+
+	bot := &anomalyBot{}
+	r := &runner{bot: bot}
+	r.reportAnomalies(1, []Anomaly{{Kind: AnomalyUnknownCell}, {Kind: AnomalyUnknownCell}})
+	r.reportAnomalies(2, []Anomaly{{Kind: AnomalySizeMismatch}})
+
+	if len(bot.reports) != 2 {
+		t.Fatalf("reports = %d, want 2", len(bot.reports))
+	}
+	first, last := bot.reports[0], bot.reports[1]
+	if first.Tick != 1 || first.Totals[AnomalyUnknownCell] != 2 || first.Totals[AnomalySizeMismatch] != 0 {
+		t.Fatalf("first report = %+v, want two unknown cells", first)
+	}
+	if last.Tick != 2 || last.Totals[AnomalyUnknownCell] != 2 || last.Totals[AnomalySizeMismatch] != 1 {
+		t.Fatalf("last report = %+v, want cumulative totals", last)
+	}
+}